@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"html"
 	"log"
 	"os"
 	"path/filepath"
@@ -19,8 +20,31 @@ func main() {
 	destDir := flag.String("dest", "", "Specify a custom destination directory for the output (default is './dist')")
 	title := flag.String("title", "", "Specify a custom title for the documentation (default is the project root name)")
 	readmePath := flag.String("readme", "", "Specify a custom README.md file to use for the index page (default is to search in project root)")
+	externalDocsURL := flag.String("external-docs-url", parser.DefaultExternalDocsURL, "Specify the documentation site used for links to packages outside the project")
+	stdInterfaces := flag.String("std-interfaces", strings.Join(parser.DefaultStdInterfaces, ","), "Specify a comma-separated list of non-project interfaces to check for implementations (e.g. 'io.Reader,fmt.Stringer'), pass an empty string to only check project interfaces")
+	goos := flag.String("goos", "", "Specify the target operating system the build constraints are evaluated for (default is the host one)")
+	goarch := flag.String("goarch", "", "Specify the target architecture the build constraints are evaluated for (default is the host one)")
+	tags := flag.String("tags", "", "Specify a comma-separated list of build tags the build constraints are evaluated with")
+	platforms := flag.String("platforms", "", "Specify a comma-separated list of GOOS/GOARCH pairs (e.g. 'linux/amd64,windows/amd64') to generate a merged view, labeling entities available only on some of them; overrides --goos and --goarch")
+	visibilityMode := flag.String("visibility", string(parser.VisibilityExported), "Specify which identifiers are documented: 'exported' for the public API only or 'all' to include unexported ones")
+	internalMode := flag.String("internal", "badge", "Specify how internal packages are handled: 'badge' to document them with an internal badge or 'exclude' to leave them out")
+	sourceURL := flag.String("source-url", "", "Specify a URL template for the entity source links, with the {path}, {line} and {endline} placeholders (e.g. 'https://github.com/org/repo/blob/main/{path}#L{line}'), by default the links point to generated source pages")
+	format := flag.String("format", "html", "Specify the output format: 'html' for the documentation site, 'json' for the documentation model, written to pallas.json, or 'markdown' for a Markdown file per package")
+	includeTests := flag.Bool("tests", false, "Document the declarations of the _test.go files too (e.g. test helpers), which are not part of the API and are left out by default")
+	inlineAssets := flag.Bool("inline-assets", false, "Embed the stylesheets and scripts in every page, for a self-contained documentation without the static directory")
 	flag.Parse()
 
+	visibility, err := parser.ParseVisibility(*visibilityMode)
+	if err != nil {
+		log.Fatalf("Error parsing flags: %v", err)
+	}
+	if *internalMode != "badge" && *internalMode != "exclude" {
+		log.Fatalf("Error parsing flags: invalid internal mode %q, expected \"badge\" or \"exclude\"", *internalMode)
+	}
+	if *format != "html" && *format != "json" && *format != "markdown" {
+		log.Fatalf("Error parsing flags: invalid format %q, expected \"html\", \"json\" or \"markdown\"", *format)
+	}
+
 	// Here we assume the project path is the first argument (if provided)
 	projectPath := "."
 	if len(flag.Args()) > 0 {
@@ -67,53 +91,132 @@ func main() {
 		docTitle = filepath.Base(absProjectPath)
 	}
 
-	// Read and convert README.md content to HTML
+	// Read the README.md content, it is converted to HTML for the HTML
+	// output only
 	readmeContent := readReadme(*readmePath, absProjectPath)
 
 	// Here is where the magic happens (parsing and generating the documentation)
-	fmt.Printf("Parsing project at path: %s\n", absProjectPath)
-	packages, err := parser.GetPackages()
-	if err != nil {
-		log.Fatalf("Error fetching packages: %v", err)
+	var buildTags []string
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			buildTags = append(buildTags, tag)
+		}
 	}
 
-	// Generate HTML for each package
-	for _, pkgPath := range packages {
-		fmt.Printf("Parsing package: %s\n", pkgPath)
-		relativePath, err := filepath.Rel(absProjectPath, pkgPath)
+	excludeInternal := *internalMode == "exclude"
+
+	// Doc comments are rendered to Markdown for the Markdown output, so that
+	// doc links point to the .md files
+	docFormat := parser.DocFormatHTML
+	if *format == "markdown" {
+		docFormat = parser.DocFormatMarkdown
+	}
+
+	// Only the HTML output has source pages
+	sourcePages := *format == "html"
+
+	var extraInterfaces []string
+	if *stdInterfaces != "" {
+		extraInterfaces = strings.Split(*stdInterfaces, ",")
+	}
+
+	fmt.Printf("Parsing project at path: %s\n", absProjectPath)
+	var parsedPackages []parser.PackageInfo
+	if *platforms != "" {
+		// each platform is parsed on its own, then the results are merged
+		// in a single view
+		targets, err := parser.ParsePlatforms(*platforms, buildTags)
 		if err != nil {
-			log.Fatalf("Error determining relative path: %v", err)
+			log.Fatalf("Error parsing platforms: %v", err)
 		}
 
-		entities, imports, err := parser.ParseEntitiesInPackage(projectPath, pkgPath, relativePath)
+		var parsedByTarget [][]parser.PackageInfo
+		for _, target := range targets {
+			fmt.Printf("Parsing platform: %s\n", target)
+			parsed, err := parseProject(absProjectPath, target, extraInterfaces, *externalDocsURL, docFormat, visibility, excludeInternal, *includeTests, sourcePages)
+			if err != nil {
+				log.Fatalf("Error parsing platform %s: %v", target, err)
+			}
+			parsedByTarget = append(parsedByTarget, parsed)
+		}
+		parsedPackages = parser.MergePlatforms(targets, parsedByTarget)
+	} else {
+		target := parser.BuildTarget{GOOS: *goos, GOARCH: *goarch, Tags: buildTags}
+		parsedPackages, err = parseProject(absProjectPath, target, extraInterfaces, *externalDocsURL, docFormat, visibility, excludeInternal, *includeTests, sourcePages)
 		if err != nil {
-			log.Fatalf("Error parsing package %s: %v", pkgPath, err)
+			log.Fatalf("Error parsing project: %v", err)
 		}
+	}
 
-		err = generator.GenerateHTML(absProjectPath, pkgPath, entities, imports, outputDir, docTitle)
-		if err != nil {
-			log.Fatalf("Error generating HTML for package %s: %v", pkgPath, err)
+	// Internal packages are not part of the public API, so they can be left
+	// out of the documentation along with the links to them
+	if excludeInternal {
+		parsedPackages = parser.ExcludeInternal(parsedPackages)
+	}
+
+	// The JSON and Markdown outputs have no source pages, so source links
+	// are only set when they point to the repository
+	if *format != "html" && *sourceURL != "" {
+		for i, pkgInfo := range parsedPackages {
+			parsedPackages[i] = parser.LinkSources(pkgInfo, *sourceURL)
+		}
+	}
+
+	switch *format {
+	case "json":
+		if err := generator.GenerateJSON(parsedPackages, outputDir, docTitle); err != nil {
+			log.Fatalf("Error generating JSON: %v", err)
+		}
+
+		fmt.Printf("Documentation model generated in %s/%s\n", outputDir, generator.JSONFileName)
+		return
+	case "markdown":
+		for _, pkgInfo := range parsedPackages {
+			if err := generator.GenerateMarkdown(pkgInfo, outputDir, docTitle); err != nil {
+				log.Fatalf("Error generating Markdown for package %s: %v", pkgInfo.Path, err)
+			}
+
+			fmt.Printf("Markdown generated for package: %s\n", pkgInfo.Path)
 		}
 
-		fmt.Printf("HTML generated for package: %s\n", pkgPath)
+		if err := generator.GenerateMarkdownIndex(parsedPackages, outputDir, docTitle, readmeContent); err != nil {
+			log.Fatalf("Error generating index.md: %v", err)
+		}
+
+		fmt.Printf("Documentation index generated in %s/index.md\n", outputDir)
+		return
 	}
 
-	// Move static assets to the output directory
-	if err := generator.CopyStaticAssets(outputDir); err != nil {
-		log.Fatalf("Error copying static assets: %v", err)
+	// Link each entity to its source, either in the repository or in the
+	// generated source pages, which are generated anyway to browse the
+	// source offline
+	for i, pkgInfo := range parsedPackages {
+		parsedPackages[i] = parser.LinkSources(pkgInfo, *sourceURL)
+	}
+	if err := generator.GenerateSourcePages(parsedPackages, outputDir, docTitle, *inlineAssets); err != nil {
+		log.Fatalf("Error generating source pages: %v", err)
 	}
 
-	// Generate the index.html file
-	packageNamesFull := make([]string, 0, len(packages))
-	for _, pkgPath := range packages {
-		relativePath, err := filepath.Rel(absProjectPath, pkgPath)
+	// Generate HTML for each package
+	for _, pkgInfo := range parsedPackages {
+		err = generator.GenerateHTML(pkgInfo, outputDir, docTitle, *inlineAssets)
 		if err != nil {
-			log.Fatalf("Error determining relative path: %v", err)
+			log.Fatalf("Error generating HTML for package %s: %v", pkgInfo.Path, err)
 		}
-		packageNamesFull = append(packageNamesFull, relativePath)
+
+		fmt.Printf("HTML generated for package: %s\n", pkgInfo.Path)
 	}
 
-	err = generator.GenerateIndex(absProjectPath, packageNamesFull, outputDir, docTitle, readmeContent)
+	// Move static assets to the output directory, inlined assets are already
+	// part of each page
+	if !*inlineAssets {
+		if err := generator.CopyStaticAssets(outputDir); err != nil {
+			log.Fatalf("Error copying static assets: %v", err)
+		}
+	}
+
+	// Generate the index.html file
+	err = generator.GenerateIndex(absProjectPath, parsedPackages, outputDir, docTitle, markdownToHTML(readmeContent), *inlineAssets)
 	if err != nil {
 		log.Fatalf("Error generating index.html: %v", err)
 	}
@@ -121,11 +224,95 @@ func main() {
 	fmt.Printf("Documentation index generated in %s/index.html\n", outputDir)
 }
 
+// parseProject loads and parses all the packages of the project for the
+// given build target, the source files are only highlighted when
+// sourcePages is set
+func parseProject(absProjectPath string, target parser.BuildTarget, extraInterfaces []string, externalDocsURL string, docFormat parser.DocFormat, visibility parser.Visibility, excludeInternal bool, includeTests bool, sourcePages bool) ([]parser.PackageInfo, error) {
+	packages, err := parser.GetPackages(target, includeTests)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching packages: %v", err)
+	}
+
+	// Interfaces are collected project-wide, so that implementations of
+	// interfaces declared in other packages are detected too. Interfaces
+	// which can not be resolved are not fatal, they are only skipped
+	interfaces, err := parser.CollectInterfaces(packages, extraInterfaces)
+	if err != nil {
+		fmt.Printf("Warning: error collecting interfaces: %v\n", err)
+	}
+
+	// Doc links are resolved against all the project packages
+	resolver := parser.NewLinkResolver(absProjectPath, packages, externalDocsURL)
+	resolver.SetFormat(docFormat)
+	if excludeInternal {
+		resolver.ExcludeInternal()
+	}
+
+	parsedPackages := make([]parser.PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		pkgPath := parser.PackageDir(pkg)
+		fmt.Printf("Parsing package: %s\n", pkgPath)
+		relativePath, err := filepath.Rel(absProjectPath, pkgPath)
+		if err != nil {
+			return nil, fmt.Errorf("error determining relative path: %v", err)
+		}
+
+		// type errors are not fatal, the package is documented with the
+		// information the type checker was able to resolve
+		for _, pkgErr := range pkg.Errors {
+			fmt.Printf("Warning: %v\n", pkgErr)
+		}
+
+		// only the package reported by go/packages is documented, files of
+		// other packages in the same directory are skipped
+		if foreignFiles := parser.ForeignFiles(pkg); len(foreignFiles) > 0 {
+			fmt.Printf("Warning: %s holds multiple packages, only package %s is documented, skipping: %s\n", relativePath, pkg.Name, strings.Join(foreignFiles, ", "))
+		}
+
+		pkgInfo, err := parser.ParsePackage(absProjectPath, pkg, relativePath, interfaces, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing package %s: %v", pkgPath, err)
+		}
+
+		// examples are not fatal either, a broken test file only leaves the
+		// package without examples
+		examples, err := parser.ParseExamples(pkg, target)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		pkgInfo = parser.AttachExamples(pkgInfo, examples)
+
+		pkgInfo = parser.FilterVisibility(pkgInfo, visibility)
+
+		if sourcePages {
+			pkgInfo.Sources, err = parser.ParseSourceFiles(pkg, relativePath, resolver, visibility)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing source files of package %s: %v", pkgPath, err)
+			}
+		}
+		parsedPackages = append(parsedPackages, pkgInfo)
+	}
+
+	return parsedPackages, nil
+}
+
 // markdownToHTML converts markdown content to HTML and applies Tailwind CSS classes
 func markdownToHTML(markdown string) string {
-	htmlContent := blackfriday.Run([]byte(markdown))
+	// The README is not generated by Pallas, so its raw HTML is skipped and
+	// only links with safe protocols are kept before marking it as trusted
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.CommonHTMLFlags | blackfriday.SkipHTML | blackfriday.Safelink,
+	})
+	htmlContent := blackfriday.Run([]byte(markdown), blackfriday.WithRenderer(renderer))
 	htmlString := string(htmlContent)
 
+	// Go code blocks are highlighted at generation time
+	goBlock := regexp.MustCompile(`(?s)<pre><code class="language-go">(.*?)</code></pre>`)
+	htmlString = goBlock.ReplaceAllStringFunc(htmlString, func(block string) string {
+		code := goBlock.FindStringSubmatch(block)[1]
+		return `<pre><code class="language-go">` + parser.HighlightGo(html.UnescapeString(code)) + `</code></pre>`
+	})
+
 	// Tailwind CSS classes
 	htmlString = strings.ReplaceAll(htmlString, "<h1", `<h1 class="text-3xl font-bold mb-4"`)
 	htmlString = strings.ReplaceAll(htmlString, "<h2", `<h2 class="text-2xl font-bold mb-4"`)
@@ -135,7 +322,7 @@ func markdownToHTML(markdown string) string {
 	htmlString = strings.ReplaceAll(htmlString, "<ol>", `<ol class="list-decimal ml-6 mb-4">`)
 	htmlString = strings.ReplaceAll(htmlString, "<li>", `<li class="mb-2">`)
 	htmlString = strings.ReplaceAll(htmlString, "<pre>", `<pre class="bg-gray-800 text-white rounded-lg p-4 overflow-auto mb-4">`)
-	htmlString = strings.ReplaceAll(htmlString, "<code>", `<code class="bg-gray-100 dark:bg-gray-800 rounded px-1 hljs">`)
+	htmlString = strings.ReplaceAll(htmlString, "<code>", `<code class="bg-gray-100 dark:bg-gray-800 rounded px-1">`)
 	htmlString = strings.ReplaceAll(htmlString, "<blockquote>", `<blockquote class="border-l-4 border-gray-300 pl-4 italic mb-4">`)
 	htmlString = strings.ReplaceAll(htmlString, "<table>", `<table class="border-collapse border border-gray-300 w-full mb-4">`)
 	htmlString = strings.ReplaceAll(htmlString, "<th>", `<th class="border border-gray-300 bg-gray-100 dark:bg-gray-800 p-2">`)
@@ -155,7 +342,8 @@ func markdownToHTML(markdown string) string {
 	return htmlString
 }
 
-// readReadme reads the README.md file and converts it to HTML
+// readReadme reads the README.md file, or returns a default content when
+// there is none
 func readReadme(customPath, projectRoot string) string {
 	var readmePath string
 	if customPath != "" {
@@ -167,10 +355,10 @@ func readReadme(customPath, projectRoot string) string {
 	content, err := os.ReadFile(readmePath)
 	if err != nil {
 		fmt.Println("README.md not found, generating default content...")
-		return markdownToHTML(generateDefaultReadme())
+		return generateDefaultReadme()
 	}
 
-	return markdownToHTML(string(content))
+	return string(content)
 }
 
 // generateDefaultReadme generates a default README.md content
@@ -0,0 +1,33 @@
+package generator
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+)
+
+// stylesheets are the bundled stylesheets loaded by every page, in order
+var stylesheets = []string{"utilities.css", "style.css"}
+
+// pageAssets returns the tags loading the bundled stylesheets of a page,
+// prefix is the path of the output directory relative to the page. When
+// inline is set the stylesheets are embedded in the page itself, so that it
+// can be opened on its own without any other file
+func pageAssets(prefix string, inline bool) (template.HTML, error) {
+	var tags strings.Builder
+	for _, stylesheet := range stylesheets {
+		if !inline {
+			fmt.Fprintf(&tags, "<link rel=\"stylesheet\" href=\"%sstatic/%s\">\n", prefix, stylesheet)
+			continue
+		}
+
+		content, err := staticAssets.ReadFile(path.Join("templates/static", stylesheet))
+		if err != nil {
+			return "", fmt.Errorf("error reading static asset: %v", err)
+		}
+		fmt.Fprintf(&tags, "<style>\n%s</style>\n", content)
+	}
+
+	return template.HTML(tags.String()), nil
+}
@@ -3,10 +3,10 @@ package generator
 import (
 	"embed"
 	"fmt"
+	"html/template"
 	"os"
+	"path"
 	"path/filepath"
-	"strings"
-	"text/template"
 
 	"github.com/vanilla-os/pallas/pkg/parser"
 )
@@ -17,40 +17,62 @@ var htmlTemplate string
 //go:embed templates/static/*
 var staticAssets embed.FS
 
-// GenerateHTML generates an HTML file for the given package and entities
-func GenerateHTML(projectPath string, packagePath string, entities []parser.EntityInfo, imports []parser.ImportInfo, outputDir string, docTitle string) error {
+// templateFuncs are the functions available in the templates
+var templateFuncs = template.FuncMap{
+	"trustedHTML": trustedHTML,
+	"highlightGo": highlightGo,
+}
+
+// trustedHTML marks HTML produced by Pallas itself (rendered doc comments,
+// highlighted source, the README converted from markdown) as safe, so that
+// html/template does not escape it. It must never be used on text coming
+// straight from the source code
+func trustedHTML(html string) template.HTML {
+	return template.HTML(html)
+}
+
+// highlightGo highlights a Go snippet at generation time, the snippet is
+// escaped by the highlighter itself
+func highlightGo(code string) template.HTML {
+	return template.HTML(parser.HighlightGo(code))
+}
+
+// GenerateHTML generates an HTML file for the given package and its entities
+func GenerateHTML(pkg parser.PackageInfo, outputDir string, docTitle string, inlineAssets bool) error {
 	// Create the output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
 		return fmt.Errorf("error creating output directory: %v", err)
 	}
 
-	tmpl, err := template.New("package").Parse(htmlTemplate)
+	tmpl, err := template.New("package").Funcs(templateFuncs).Parse(htmlTemplate)
 	if err != nil {
 		return err
 	}
 
-	// Extract the relative path of the package based on the project path
-	relativePackagePath, err := filepath.Rel(projectPath, packagePath)
+	stylesheets, err := pageAssets("", inlineAssets)
 	if err != nil {
 		return err
 	}
 
-	// Replace slashes with hyphens to ensure unique filenames
-	safeFileName := strings.ReplaceAll(relativePackagePath, string(os.PathSeparator), "-")
-
 	// Generate the HTML file
-	filePath := filepath.Join(outputDir, fmt.Sprintf("%s.html", safeFileName))
+	filePath := filepath.Join(outputDir, fmt.Sprintf("%s.html", pkg.URL))
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	// Determine if the package has functions, types, and structs
+	entities := pkg.Entities
+	imports := pkg.Imports
+
+	// Determine if the package has functions, types, structs, constants and
+	// variables
 	hasFunctions := false
 	hasTypes := false
 	hasStructs := false
 	hasInterfaces := false
+	hasConstants := false
+	hasVariables := false
 	hasImports := len(imports) > 0
 	for _, entity := range entities {
 		if entity.Type == "function" {
@@ -61,28 +83,59 @@ func GenerateHTML(projectPath string, packagePath string, entities []parser.Enti
 			hasStructs = true
 		} else if entity.Type == "interface" {
 			hasInterfaces = true
+		} else if entity.Type == "const" {
+			hasConstants = true
+		} else if entity.Type == "var" {
+			hasVariables = true
 		}
 	}
 
+	// Source files link to their generated pages
+	var files []PackageLink
+	for _, file := range pkg.Files {
+		files = append(files, PackageLink{
+			Name: path.Base(file),
+			Link: parser.SourcePageURL(file),
+		})
+	}
+
 	data := struct {
 		PackageName   string
+		IsCommand     bool
+		IsInternal    bool
+		PackageDoc    string
+		Platforms     []string
+		Examples      []parser.ExampleInfo
+		Files         []PackageLink
 		Entities      []parser.EntityInfo
 		Imports       []parser.ImportInfo
 		Title         string
+		Stylesheets   template.HTML
 		HasFunctions  bool
 		HasTypes      bool
 		HasStructs    bool
 		HasInterfaces bool
+		HasConstants  bool
+		HasVariables  bool
 		HasImports    bool
 	}{
-		PackageName:   relativePackagePath,
+		PackageName:   pkg.DisplayName(),
+		IsCommand:     pkg.IsCommand(),
+		IsInternal:    pkg.IsInternal(),
+		PackageDoc:    pkg.Doc,
+		Platforms:     pkg.Platforms,
+		Examples:      pkg.Examples,
+		Files:         files,
 		Entities:      entities,
 		Imports:       imports,
 		Title:         docTitle,
+		Stylesheets:   stylesheets,
 		HasFunctions:  hasFunctions,
 		HasTypes:      hasTypes,
 		HasStructs:    hasStructs,
 		HasInterfaces: hasInterfaces,
+		HasConstants:  hasConstants,
+		HasVariables:  hasVariables,
 		HasImports:    hasImports,
 	}
 
@@ -0,0 +1,234 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
+)
+
+// linkFixture is a project whose documented entities refer to unexported
+// symbols and to an internal package, which are filtered out depending on
+// the visibility and on the handling of internal packages
+var linkFixture = map[string]string{
+	"internal/secret/secret.go": "package secret\n\n// Key is a key\ntype Key struct{}\n\n// Open opens\nfunc (Key) Open() {}\n\n// Opener opens\ntype Opener interface{ Open() }\n",
+	"lib/lib.go":                "package lib\n\ntype inner struct{}\n\n// Run runs\nfunc (inner) Run() {}\n\n// Base is embedded\ntype Base struct{ inner }\n\n// Reader reads\ntype Reader interface{ Read() }\n",
+	"api/api.go": `package api
+
+import (
+	"example.com/fx/internal/secret"
+	"example.com/fx/lib"
+)
+
+type base struct{}
+
+// Do does
+func (b *base) Do() {}
+
+type hidden interface{ Close() error }
+
+// Outer embeds [lib.Base] and holds a [secret.Key]
+type Outer struct {
+	base
+	lib.Base
+	secret.Key
+	key secret.Key
+}
+
+// Closer embeds interfaces
+type Closer interface {
+	hidden
+	lib.Reader
+}
+
+// File implements [secret.Opener]
+type File struct{ h hidden }
+
+// Open opens
+func (File) Open() {}
+
+// New returns a key, see [Outer.Do] and [Outer.Run]
+func New() secret.Key { return secret.Key{} }
+
+func helper() base { return base{} }
+
+const (
+	first = iota
+	// Second is exported
+	Second
+)
+`,
+}
+
+// hrefs matches the links of the generated pages
+var hrefs = regexp.MustCompile(`href="([^"]*)"`)
+
+func TestGeneratedLinks(t *testing.T) {
+	tests := []struct {
+		visibility      parser.Visibility
+		excludeInternal bool
+	}{
+		{parser.VisibilityExported, false},
+		{parser.VisibilityExported, true},
+		{parser.VisibilityAll, false},
+		{parser.VisibilityAll, true},
+	}
+
+	for _, test := range tests {
+		name := string(test.visibility)
+		if test.excludeInternal {
+			name += " without internal"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			outputDir := generateFixture(t, linkFixture, test.visibility, test.excludeInternal)
+
+			pages, err := filepath.Glob(filepath.Join(outputDir, "*.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sources, err := filepath.Glob(filepath.Join(outputDir, "source", "*.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			links := 0
+			for _, page := range append(pages, sources...) {
+				content, err := os.ReadFile(page)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, match := range hrefs.FindAllStringSubmatch(string(content), -1) {
+					href := match[1]
+					if strings.HasPrefix(href, "https://") || strings.Contains(href, "static/") {
+						continue
+					}
+					links++
+
+					target, anchor, _ := strings.Cut(href, "#")
+					targetPage := page
+					if target != "" {
+						targetPage = filepath.Join(filepath.Dir(page), filepath.FromSlash(target))
+					}
+					targetContent, err := os.ReadFile(targetPage)
+					if err != nil {
+						t.Errorf("%s links %s, which is not generated", filepath.Base(page), href)
+						continue
+					}
+					if anchor != "" && !strings.Contains(string(targetContent), `id="`+anchor+`"`) {
+						t.Errorf("%s links %s, which has no such anchor", filepath.Base(page), href)
+					}
+				}
+			}
+			if links == 0 {
+				t.Error("no link checked")
+			}
+		})
+	}
+}
+
+// generateFixture writes the files of a fixture module, named
+// example.com/fx, parses it as Pallas does and generates its HTML pages,
+// returning the output directory
+func generateFixture(t *testing.T, files map[string]string, visibility parser.Visibility, excludeInternal bool) string {
+	t.Helper()
+
+	dir, packages := parseFixture(t, files, parser.DocFormatHTML, visibility, excludeInternal)
+	for i, pkgInfo := range packages {
+		packages[i] = parser.LinkSources(pkgInfo, "")
+	}
+
+	outputDir := filepath.Join(dir, "dist")
+	if err := GenerateSourcePages(packages, outputDir, "fx", false); err != nil {
+		t.Fatalf("GenerateSourcePages() error = %v", err)
+	}
+	for _, pkgInfo := range packages {
+		if err := GenerateHTML(pkgInfo, outputDir, "fx", false); err != nil {
+			t.Fatalf("GenerateHTML(%s) error = %v", pkgInfo.Path, err)
+		}
+	}
+	if err := GenerateIndex(dir, packages, outputDir, "fx", "", false); err != nil {
+		t.Fatalf("GenerateIndex() error = %v", err)
+	}
+
+	return outputDir
+}
+
+// parseFixture writes the files of a fixture module, named example.com/fx,
+// to a temporary directory and parses its packages as Pallas does
+func parseFixture(t *testing.T, files map[string]string, format parser.DocFormat, visibility parser.Visibility, excludeInternal bool) (string, []parser.PackageInfo) {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/fx\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the fixture is a module of its own, the flags of the go command
+	// running the tests do not apply to it
+	t.Setenv("GOFLAGS", "")
+
+	// GetPackages loads the packages of the current directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	pkgs, err := parser.GetPackages(parser.BuildTarget{}, false)
+	if err != nil {
+		t.Fatalf("GetPackages() error = %v", err)
+	}
+	interfaces, err := parser.CollectInterfaces(pkgs, nil)
+	if err != nil {
+		t.Fatalf("CollectInterfaces() error = %v", err)
+	}
+	resolver := parser.NewLinkResolver(dir, pkgs, parser.DefaultExternalDocsURL)
+	resolver.SetFormat(format)
+	if excludeInternal {
+		resolver.ExcludeInternal()
+	}
+
+	var packages []parser.PackageInfo
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			t.Fatalf("package %s: %v", pkg.PkgPath, pkgErr)
+		}
+
+		relativePath, err := filepath.Rel(dir, parser.PackageDir(pkg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgInfo, err := parser.ParsePackage(dir, pkg, relativePath, interfaces, resolver)
+		if err != nil {
+			t.Fatalf("ParsePackage(%s) error = %v", relativePath, err)
+		}
+		pkgInfo = parser.FilterVisibility(pkgInfo, visibility)
+		pkgInfo.Sources, err = parser.ParseSourceFiles(pkg, relativePath, resolver, visibility)
+		if err != nil {
+			t.Fatalf("ParseSourceFiles(%s) error = %v", relativePath, err)
+		}
+		packages = append(packages, pkgInfo)
+	}
+	if excludeInternal {
+		packages = parser.ExcludeInternal(packages)
+	}
+
+	return dir, packages
+}
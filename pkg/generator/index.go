@@ -2,29 +2,44 @@ package generator
 
 import (
 	_ "embed"
+	"html/template"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"text/template"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
 )
 
 //go:embed templates/index.html
 var indexTemplate string
 
 type PackageLink struct {
-	Name string
-	Link string
+	Name     string
+	Link     string
+	Synopsis string
+	Internal bool
+}
+
+// PackageGroup is a group of packages sharing the same path prefix
+type PackageGroup struct {
+	Name     string
+	Packages []PackageLink
 }
 
 // GenerateIndex generates the index.html file listing all the documented packages
-func GenerateIndex(projectPath string, packages []string, outputDir string, docTitle string, readmeContent string) error {
+func GenerateIndex(projectPath string, packages []parser.PackageInfo, outputDir string, docTitle string, readmeContent string, inlineAssets bool) error {
 	// Create the output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
 		return err
 	}
 
-	tmpl, err := template.New("index").Parse(indexTemplate)
+	tmpl, err := template.New("index").Funcs(templateFuncs).Parse(indexTemplate)
+	if err != nil {
+		return err
+	}
+
+	stylesheets, err := pageAssets("", inlineAssets)
 	if err != nil {
 		return err
 	}
@@ -35,40 +50,69 @@ func GenerateIndex(projectPath string, packages []string, outputDir string, docT
 	}
 	defer file.Close()
 
-	// Group packages by their prefix
+	commands, groups := groupPackages(packages, ".html")
+
+	// Execute template with data
+	return tmpl.Execute(file, struct {
+		Title           string
+		Stylesheets     template.HTML
+		Commands        []PackageLink
+		GroupedPackages []PackageGroup
+		TotalPackages   int
+		ReadmeContent   string
+	}{
+		Title:           docTitle,
+		Stylesheets:     stylesheets,
+		Commands:        commands,
+		GroupedPackages: groups,
+		TotalPackages:   len(packages),
+		ReadmeContent:   readmeContent,
+	})
+}
+
+// groupPackages groups the packages by the first element of their path,
+// linking their pages with the given extension. Commands (package main) are
+// returned on their own and everything is sorted, so that the index is the
+// same on every run
+func groupPackages(packages []parser.PackageInfo, extension string) ([]PackageLink, []PackageGroup) {
 	groupedPackages := make(map[string][]PackageLink)
-	var totalPackages int
+	var commands []PackageLink
 
 	for _, pkg := range packages {
-		parts := strings.Split(pkg, string(os.PathSeparator))
-		prefix := parts[0]
+		link := PackageLink{
+			Name:     pkg.DisplayName(),
+			Link:     pkg.URL + extension,
+			Synopsis: pkg.Synopsis,
+			Internal: pkg.IsInternal(),
+		}
 
-		safeFileName := strings.ReplaceAll(pkg, string(os.PathSeparator), "-")
-		groupedPackages[prefix] = append(groupedPackages[prefix], PackageLink{
-			Name: pkg,
-			Link: safeFileName + ".html",
-		})
+		if pkg.IsCommand() {
+			commands = append(commands, link)
+			continue
+		}
 
-		totalPackages++
+		// the root package is grouped on its own, under its import path
+		prefix := pkg.DisplayName()
+		if pkg.Path != "." {
+			prefix = strings.Split(pkg.Path, string(os.PathSeparator))[0]
+		}
+		groupedPackages[prefix] = append(groupedPackages[prefix], link)
 	}
 
-	// Sort packages within each group
-	for _, packages := range groupedPackages {
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	var groups []PackageGroup
+	for prefix, packages := range groupedPackages {
 		sort.Slice(packages, func(i, j int) bool {
 			return packages[i].Name < packages[j].Name
 		})
+		groups = append(groups, PackageGroup{Name: prefix, Packages: packages})
 	}
-
-	// Execute template with data
-	return tmpl.Execute(file, struct {
-		Title           string
-		GroupedPackages map[string][]PackageLink
-		TotalPackages   int
-		ReadmeContent   string
-	}{
-		Title:           docTitle,
-		GroupedPackages: groupedPackages,
-		TotalPackages:   totalPackages,
-		ReadmeContent:   readmeContent,
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
 	})
+
+	return commands, groups
 }
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
+)
+
+// JSONSchemaVersion is the version of the JSON documentation schema, it is
+// increased on every change which is not backward compatible (removed or
+// renamed fields, changed types), while new fields can be added at any time
+const JSONSchemaVersion = 1
+
+// JSONFileName is the name of the JSON documentation in the output directory
+const JSONFileName = "pallas.json"
+
+// JSONDocument is the root of the JSON documentation
+type JSONDocument struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	Title         string               `json:"title"`
+	Packages      []parser.PackageInfo `json:"packages"`
+}
+
+// GenerateJSON writes the documentation model of the given packages to the
+// pallas.json file of the output directory, for other tools to consume
+//
+// Example:
+//
+//	err := generator.GenerateJSON(packages, "dist", "My Project")
+//	if err != nil {
+//		log.Fatalf("Error generating JSON: %v", err)
+//	}
+func GenerateJSON(packages []parser.PackageInfo, outputDir string, docTitle string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	document := JSONDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Title:         docTitle,
+		Packages:      packages,
+	}
+	if document.Packages == nil {
+		document.Packages = []parser.PackageInfo{}
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+
+	outputFile := filepath.Join(outputDir, JSONFileName)
+	if err := os.WriteFile(outputFile, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing JSON file: %v", err)
+	}
+
+	return nil
+}
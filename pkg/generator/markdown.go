@@ -0,0 +1,195 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
+)
+
+//go:embed templates/package.md
+var markdownTemplate string
+
+//go:embed templates/index.md
+var markdownIndexTemplate string
+
+// markdownFuncs are the functions available in the Markdown templates
+var markdownFuncs = template.FuncMap{
+	"anchor":    parser.MarkdownAnchor,
+	"codeBlock": codeBlock,
+	"codeSpan":  codeSpan,
+	"cell":      tableCell,
+	"codeCell":  codeCell,
+	"join":      strings.Join,
+}
+
+// EntitySection is a section of a Markdown package page, grouping the
+// entities of the same kind
+type EntitySection struct {
+	Name     string
+	Entities []parser.EntityInfo
+}
+
+// sectionOrder is the order of the sections of a Markdown package page,
+// which is the one of the HTML sidebar
+var sectionOrder = []struct {
+	Type string
+	Name string
+}{
+	{"const", "Constants"},
+	{"var", "Variables"},
+	{"function", "Functions"},
+	{"struct", "Structs"},
+	{"interface", "Interfaces"},
+	{"type", "Types"},
+}
+
+// GenerateMarkdown generates a Markdown file for the given package and its
+// entities, the doc comments of the package must have been rendered with
+// the Markdown format (see parser.LinkResolver.SetFormat)
+//
+// Example:
+//
+//	err := generator.GenerateMarkdown(pkgInfo, "dist", "My Project")
+//	if err != nil {
+//		log.Fatalf("Error generating Markdown: %v", err)
+//	}
+func GenerateMarkdown(pkg parser.PackageInfo, outputDir string, docTitle string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	tmpl, err := template.New("package").Funcs(markdownFuncs).Parse(markdownTemplate)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(outputDir, fmt.Sprintf("%s.md", pkg.URL)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var sections []EntitySection
+	for _, section := range sectionOrder {
+		var entities []parser.EntityInfo
+		for _, entity := range pkg.Entities {
+			if entity.Type == section.Type {
+				entities = append(entities, entity)
+			}
+		}
+		if len(entities) > 0 {
+			sections = append(sections, EntitySection{Name: section.Name, Entities: entities})
+		}
+	}
+
+	return tmpl.Execute(file, struct {
+		PackageName string
+		IsCommand   bool
+		IsInternal  bool
+		PackageDoc  string
+		Platforms   []string
+		Examples    []parser.ExampleInfo
+		Sections    []EntitySection
+		Imports     []parser.ImportInfo
+		Files       []string
+		Title       string
+	}{
+		PackageName: pkg.DisplayName(),
+		IsCommand:   pkg.IsCommand(),
+		IsInternal:  pkg.IsInternal(),
+		PackageDoc:  pkg.Doc,
+		Platforms:   pkg.Platforms,
+		Examples:    pkg.Examples,
+		Sections:    sections,
+		Imports:     pkg.Imports,
+		Files:       pkg.Files,
+		Title:       docTitle,
+	})
+}
+
+// GenerateMarkdownIndex generates the index.md file listing all the
+// documented packages after the README, which is included as is
+func GenerateMarkdownIndex(packages []parser.PackageInfo, outputDir string, docTitle string, readmeContent string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	tmpl, err := template.New("index").Funcs(markdownFuncs).Parse(markdownIndexTemplate)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(outputDir, "index.md"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	commands, groups := groupPackages(packages, ".md")
+
+	return tmpl.Execute(file, struct {
+		Title           string
+		Commands        []PackageLink
+		GroupedPackages []PackageGroup
+		ReadmeContent   string
+	}{
+		Title:           docTitle,
+		Commands:        commands,
+		GroupedPackages: groups,
+		ReadmeContent:   strings.TrimSpace(readmeContent),
+	})
+}
+
+// codeBlock returns a fenced code block, the fence is longer than any run
+// of backticks in the code so that it can not be closed early
+func codeBlock(language string, code string) string {
+	fence := strings.Repeat("`", max(3, longestBacktickRun(code)+1))
+	return fence + language + "\n" + strings.TrimRight(code, "\n") + "\n" + fence
+}
+
+// codeSpan returns an inline code span, delimited by more backticks than the
+// code contains
+func codeSpan(code string) string {
+	code = strings.ReplaceAll(code, "\n", " ")
+	run := longestBacktickRun(code)
+	if run == 0 {
+		return "`" + code + "`"
+	}
+
+	delimiter := strings.Repeat("`", run+1)
+	return delimiter + " " + code + " " + delimiter
+}
+
+// tableCell escapes text from the source code for a table cell, which must
+// fit in a single line and can not contain unescaped pipes, nor HTML tags
+func tableCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\n", " ")
+	text = strings.ReplaceAll(text, "<", `\<`)
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// codeCell returns a code span for a table cell, pipes must be escaped even
+// within code spans
+func codeCell(code string) string {
+	return strings.ReplaceAll(codeSpan(code), "|", `\|`)
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in
+// a text
+func longestBacktickRun(text string) int {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -0,0 +1,189 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
+)
+
+func TestCodeSpan(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"int", "`int`"},
+		{"a `b` c", "`` a `b` c ``"},
+		{"``x``", "``` ``x`` ```"},
+		{"`", "`` ` ``"},
+		{"struct {\n\ta int\n}", "`struct { \ta int }`"},
+	}
+
+	for _, test := range tests {
+		if got := codeSpan(test.code); got != test.want {
+			t.Errorf("codeSpan(%q) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+func TestCodeBlock(t *testing.T) {
+	tests := []struct {
+		language string
+		code     string
+		want     string
+	}{
+		{"go", "x := 1\n", "```go\nx := 1\n```"},
+		{"", "```\nnested\n```", "````\n```\nnested\n```\n````"},
+	}
+
+	for _, test := range tests {
+		if got := codeBlock(test.language, test.code); got != test.want {
+			t.Errorf("codeBlock(%q, %q) = %q, want %q", test.language, test.code, got, test.want)
+		}
+	}
+}
+
+func TestTableCell(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"  trimmed\n", "trimmed"},
+		{"one\ntwo", "one two"},
+		{"a | b", `a \| b`},
+		{"<b>bold</b>", `\<b>bold\</b>`},
+	}
+
+	for _, test := range tests {
+		if got := tableCell(test.text); got != test.want {
+			t.Errorf("tableCell(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestCodeCell(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"string", "`string`"},
+		{"~int | ~string", "`~int \\| ~string`"},
+		{"json:\"a|b\"", "`json:\"a\\|b\"`"},
+		{"`tag`", "`` `tag` ``"},
+	}
+
+	for _, test := range tests {
+		if got := codeCell(test.code); got != test.want {
+			t.Errorf("codeCell(%q) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+// markdownLinks matches the links of the generated Markdown pages to other
+// pages or anchors of the project
+var markdownLinks = regexp.MustCompile(`\]\(([^)]*\.md)?(#[^)]*)?\)`)
+
+// markdownAnchors matches the headings and the explicit anchors of the
+// generated Markdown pages
+var markdownAnchors = regexp.MustCompile(`(?m)^#+ (.*)$|<a id="([^"]*)"></a>`)
+
+func TestMarkdownLinks(t *testing.T) {
+	dir, packages := parseFixture(t, map[string]string{
+		"lib/lib.go": "package lib\n\n// Reader reads\ntype Reader interface{ Read() }\n",
+		"fx.go": `package fx
+
+import "example.com/fx/lib"
+
+// Limits, see [MaxB], [Foo.Bar] and [FooBar]
+const (
+	// MaxA is a
+	MaxA = 1
+	// MaxB is b
+	MaxB = 2
+)
+
+// Foo is foo
+type Foo struct{ lib.Reader }
+
+// Bar bars
+func (Foo) Bar() {}
+
+// FooBar is foobar, see [lib.Reader]
+type FooBar int
+`,
+	}, parser.DocFormatMarkdown, parser.VisibilityExported, false)
+
+	outputDir := filepath.Join(dir, "dist")
+	for _, pkgInfo := range packages {
+		if err := GenerateMarkdown(pkgInfo, outputDir, "fx"); err != nil {
+			t.Fatalf("GenerateMarkdown(%s) error = %v", pkgInfo.Path, err)
+		}
+	}
+
+	if err := GenerateMarkdownIndex(packages, outputDir, "fx", ""); err != nil {
+		t.Fatalf("GenerateMarkdownIndex() error = %v", err)
+	}
+
+	// anchors of each page, mapped to the number of elements using them
+	anchors := make(map[string]map[string]int)
+	pages := make(map[string]string)
+	for _, page := range append(packageFiles(packages), "index.md") {
+		content, err := os.ReadFile(filepath.Join(outputDir, page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[page] = string(content)
+
+		anchors[page] = make(map[string]int)
+		for _, match := range markdownAnchors.FindAllStringSubmatch(string(content), -1) {
+			anchor := match[2]
+			if match[1] != "" {
+				// the headings hold no dots, their anchors are plain slugs
+				anchor = parser.MarkdownAnchor(match[1])
+			}
+			anchors[page][anchor]++
+		}
+	}
+
+	links := 0
+	for page, content := range pages {
+		for _, match := range markdownLinks.FindAllStringSubmatch(content, -1) {
+			target, anchor := match[1], strings.TrimPrefix(match[2], "#")
+			if target == "" {
+				target = page
+			}
+			if _, ok := pages[target]; !ok {
+				t.Errorf("%s links %s, which is not generated", page, match[0])
+				continue
+			}
+			if anchor == "" {
+				continue
+			}
+			links++
+
+			switch anchors[target][anchor] {
+			case 0:
+				t.Errorf("%s links %s, which has no such anchor", page, match[0])
+			case 1:
+			default:
+				t.Errorf("%s links %s, whose anchor is not unique", page, match[0])
+			}
+		}
+	}
+	if links == 0 {
+		t.Error("no link checked")
+	}
+}
+
+// packageFiles returns the names of the Markdown pages of the packages
+func packageFiles(packages []parser.PackageInfo) []string {
+	var files []string
+	for _, pkgInfo := range packages {
+		files = append(files, pkgInfo.URL+".md")
+	}
+	return files
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/vanilla-os/pallas/pkg/parser"
+)
+
+//go:embed templates/source.html
+var sourceTemplate string
+
+// SourceLine is a line of a source file page
+type SourceLine struct {
+	Number int
+	Code   string
+}
+
+// GenerateSourcePages generates an HTML page for each Go source file of the
+// given packages, with line numbers, line anchors (#L12) and highlighted
+// code where the identifiers link back to the entities documentation
+func GenerateSourcePages(packages []parser.PackageInfo, outputDir string, docTitle string, inlineAssets bool) error {
+	if err := os.MkdirAll(filepath.Join(outputDir, "source"), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating source directory: %v", err)
+	}
+
+	tmpl, err := template.New("source").Funcs(templateFuncs).Parse(sourceTemplate)
+	if err != nil {
+		return err
+	}
+
+	// source pages live in the source directory, one level below the others
+	stylesheets, err := pageAssets("../", inlineAssets)
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range packages {
+		for _, source := range pkg.Sources {
+			if err := generateSourcePage(tmpl, source, outputDir, docTitle, stylesheets); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateSourcePage generates the page of a single source file
+func generateSourcePage(tmpl *template.Template, source parser.SourceFile, outputDir string, docTitle string, stylesheets template.HTML) error {
+	var lines []SourceLine
+	for i, line := range source.Lines {
+		lines = append(lines, SourceLine{
+			Number: i + 1,
+			Code:   line,
+		})
+	}
+
+	file, err := os.Create(filepath.Join(outputDir, filepath.FromSlash(parser.SourcePageURL(source.Path))))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, struct {
+		Title       string
+		Stylesheets template.HTML
+		File        string
+		PackageName string
+		PackageLink string
+		Lines       []SourceLine
+	}{
+		Title:       docTitle,
+		Stylesheets: stylesheets,
+		File:        source.Path,
+		PackageName: source.PackageName,
+		PackageLink: "../" + source.PackageURL + ".html",
+		Lines:       lines,
+	})
+}
@@ -0,0 +1,265 @@
+package parser
+
+import (
+	"go/doc/comment"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultExternalDocsURL is the documentation site used for doc links to
+// packages which are not part of the project
+const DefaultExternalDocsURL = "https://pkg.go.dev"
+
+// DocFormat is the markup doc comments are rendered to
+type DocFormat string
+
+const (
+	// DocFormatHTML renders doc comments to HTML, linking the .html pages
+	DocFormatHTML DocFormat = "html"
+
+	// DocFormatMarkdown renders doc comments to Markdown, linking the .md
+	// pages and the anchors of their headings
+	DocFormatMarkdown DocFormat = "markdown"
+)
+
+// LinkResolver resolves the doc links ([Name], [pkg.Name], [Type.Method])
+// found in doc comments, links to project packages point to the generated
+// pages while the others point to an external documentation site
+type LinkResolver struct {
+	format       DocFormat
+	externalURL  string
+	packagePaths map[string]string
+	packageNames map[string][]string
+	excluded     map[string]bool
+}
+
+// NewLinkResolver creates a LinkResolver for the given project packages
+//
+// Example:
+//
+//	resolver := parser.NewLinkResolver("/home/me/myproject", pkgs, parser.DefaultExternalDocsURL)
+func NewLinkResolver(projectPath string, pkgs []*packages.Package, externalURL string) *LinkResolver {
+	resolver := &LinkResolver{
+		format:       DocFormatHTML,
+		externalURL:  strings.TrimSuffix(externalURL, "/"),
+		packagePaths: make(map[string]string),
+		packageNames: make(map[string][]string),
+		excluded:     make(map[string]bool),
+	}
+
+	for _, pkg := range pkgs {
+		relativePath, err := filepath.Rel(projectPath, PackageDir(pkg))
+		if err != nil {
+			continue
+		}
+		resolver.packagePaths[pkg.PkgPath] = relativePath
+		resolver.packageNames[pkg.Name] = append(resolver.packageNames[pkg.Name], pkg.PkgPath)
+	}
+
+	return resolver
+}
+
+// ExcludeInternal leaves the internal packages of the project unlinked, for
+// when they are left out of the documentation: doc links to them render as
+// plain text and their identifiers in the source pages are not linked
+func (r *LinkResolver) ExcludeInternal() {
+	for importPath := range r.packagePaths {
+		if isInternalPath(importPath) {
+			delete(r.packagePaths, importPath)
+			r.excluded[importPath] = true
+		}
+	}
+}
+
+// SetFormat sets the markup the doc comments are rendered to, HTML by
+// default
+func (r *LinkResolver) SetFormat(format DocFormat) {
+	r.format = format
+}
+
+// DocRenderer renders the doc comments of a single package, resolving the
+// doc links against the package scope and imports
+type DocRenderer struct {
+	resolver *LinkResolver
+	pkg      *types.Package
+	imports  map[string]string
+}
+
+// ForPackage returns a DocRenderer for the given package
+func (r *LinkResolver) ForPackage(pkg *packages.Package) *DocRenderer {
+	renderer := &DocRenderer{
+		resolver: r,
+		pkg:      pkg.Types,
+		imports:  make(map[string]string),
+	}
+
+	importNames := make(map[string]string)
+	if pkg.Types != nil {
+		for _, imp := range pkg.Types.Imports() {
+			importNames[imp.Path()] = imp.Name()
+		}
+	}
+
+	for _, file := range packageFiles(pkg) {
+		for _, imp := range file.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+
+			name := importNames[importPath]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name == "" || name == "_" || name == "." {
+				continue
+			}
+			renderer.imports[name] = importPath
+		}
+	}
+
+	return renderer
+}
+
+// Render renders a doc comment to HTML (or Markdown, depending on the
+// format of the resolver) with the go/doc/comment package, so that
+// headings, lists, code blocks and links are rendered the same way as on
+// pkg.go.dev
+func (d *DocRenderer) Render(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	commentParser := comment.Parser{
+		LookupPackage: d.lookupPackage,
+		LookupSym:     d.lookupSym,
+	}
+	parsed := commentParser.Parse(text)
+
+	printer := comment.Printer{
+		HeadingLevel: 4,
+		DocLinkURL:   d.docLinkURL,
+	}
+	if d.resolver.format == DocFormatMarkdown {
+		return string(printer.Markdown(parsed))
+	}
+	return string(printer.HTML(parsed))
+}
+
+// lookupPackage resolves a package name used in a doc link to its import
+// path, looking at the package imports first and at the project packages
+// then
+func (d *DocRenderer) lookupPackage(name string) (string, bool) {
+	if importPath, ok := d.imports[name]; ok {
+		return importPath, true
+	}
+	if d.pkg != nil && name == d.pkg.Name() {
+		return "", true
+	}
+	if importPaths := d.resolver.packageNames[name]; len(importPaths) == 1 {
+		return importPaths[0], true
+	}
+	return "", false
+}
+
+// lookupSym reports whether a symbol, or a method of a type, exists in the
+// package. Struct fields are not documented with an anchor of their own, so
+// they are not resolved
+func (d *DocRenderer) lookupSym(recv string, name string) bool {
+	if d.pkg == nil {
+		return false
+	}
+
+	if recv == "" {
+		return d.pkg.Scope().Lookup(name) != nil
+	}
+
+	typeName, ok := d.pkg.Scope().Lookup(recv).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typeName.Type(), true, d.pkg, name)
+	_, isMethod := obj.(*types.Func)
+	return isMethod
+}
+
+// docLinkURL returns the URL of a doc link target, the anchors follow the
+// ones of the generated pages (Name and Type.Method)
+func (d *DocRenderer) docLinkURL(link *comment.DocLink) string {
+	anchor := link.Name
+	if link.Recv != "" {
+		anchor = link.Recv + "." + link.Name
+	}
+
+	importPath := link.ImportPath
+	if d.pkg != nil && importPath == d.pkg.Path() {
+		importPath = ""
+	}
+
+	// the anchors of the project pages are the ones of their headings in
+	// Markdown, while external sites keep their own
+	pageAnchor := anchor
+	extension := ".html"
+	if d.resolver.format == DocFormatMarkdown {
+		pageAnchor = MarkdownAnchor(anchor)
+		extension = ".md"
+	}
+
+	// excluded packages have no page to link
+	if d.resolver.excluded[importPath] {
+		return ""
+	}
+
+	// links to the current package
+	if importPath == "" {
+		return "#" + pageAnchor
+	}
+
+	// links to other packages of the project
+	if relativePath, ok := d.resolver.packagePaths[importPath]; ok {
+		url := packageURL(relativePath) + extension
+		if pageAnchor != "" {
+			url += "#" + pageAnchor
+		}
+		return url
+	}
+
+	url := d.resolver.externalURL + "/" + importPath
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	return url
+}
+
+// MarkdownAnchor returns the anchor of an entity (Name) or of a method
+// (Type.Method) in the Markdown pages, which is the one Markdown renderers
+// such as GitHub and GitLab generate for its heading: lowercase, with spaces
+// replaced by hyphens and punctuation other than hyphens and underscores
+// removed. Methods are headed "func (Type) Method", so that their anchors
+// can not collide with the ones of the entities, e.g. FooBar and Foo.Bar
+//
+// Example:
+//
+//	anchor := parser.MarkdownAnchor("Reader.Read") // "func-reader-read"
+func MarkdownAnchor(name string) string {
+	heading := name
+	if recv, method, ok := strings.Cut(name, "."); ok {
+		heading = "func (" + recv + ") " + method
+	}
+
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			anchor.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			anchor.WriteRune(r)
+		}
+	}
+	return anchor.String()
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocLinks(t *testing.T) {
+	for _, visibility := range []Visibility{VisibilityExported, VisibilityAll} {
+		t.Run(string(visibility), func(t *testing.T) {
+			parsed := parseFixture(t, map[string]string{
+				"lib/lib.go": "package lib\n\n// Public is exported\nfunc Public() {}\n\nfunc secret() {}\n",
+				"api/api.go": "package api\n\nimport _ \"example.com/fx/lib\"\n\n// Cfg is a config\ntype Cfg struct{ Name string }\n\n// Load loads\nfunc (Cfg) Load() {}\n\nfunc (Cfg) reload() {}\n\ntype hidden struct{}\n\n// Run uses [Cfg.Name], [Cfg.Load], [Cfg.reload], [hidden], [lib.secret] and [lib.Public]\nfunc Run() {}\n",
+			}, visibility)
+			description := findEntity(t, parsed["api"], "Run").Description
+
+			// fields have no anchor, unexported symbols are plain text
+			// whatever the visibility
+			for _, want := range []string{`<a href="#Cfg.Load">`, `<a href="lib.html#Public">`, "[Cfg.Name]", "[Cfg.reload]", "[hidden]", "[lib.secret]"} {
+				if !strings.Contains(description, want) {
+					t.Errorf("description does not contain %s:\n%s", want, description)
+				}
+			}
+			if got := strings.Count(description, "<a "); got != 2 {
+				t.Errorf("description has %d links, want 2:\n%s", got, description)
+			}
+		})
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Reader", "reader"},
+		{"MaxB", "maxb"},
+		{"snake_case", "snake_case"},
+		{"Reader.Read", "func-reader-read"},
+		{"FooBar", "foobar"},
+		{"Foo.Bar", "func-foo-bar"},
+		{"Fo.oBar", "func-fo-obar"},
+		{"Ünïcode", "ünïcode"},
+	}
+
+	for _, test := range tests {
+		if got := MarkdownAnchor(test.name); got != test.want {
+			t.Errorf("MarkdownAnchor(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -1,56 +1,151 @@
 package parser
 
+import "go/types"
+
+// PackageInfo contains relevant information about a package and the
+// entities it declares
+type PackageInfo struct {
+	Name       string        `json:"name"`
+	ImportPath string        `json:"importPath"`
+	Path       string        `json:"path"`
+	URL        string        `json:"url"`
+	Doc        string        `json:"doc,omitempty"`
+	Synopsis   string        `json:"synopsis,omitempty"`
+	Entities   []EntityInfo  `json:"entities,omitempty"`
+	Imports    []ImportInfo  `json:"imports,omitempty"`
+	Examples   []ExampleInfo `json:"examples,omitempty"`
+	Platforms  []string      `json:"platforms,omitempty"`
+	Files      []string      `json:"files,omitempty"`
+	Sources    []SourceFile  `json:"-"`
+
+	// Raw fields
+	DocRaw string `json:"docRaw,omitempty"`
+}
+
+// IsCommand reports whether the package is a command (package main)
+func (p PackageInfo) IsCommand() bool {
+	return p.Name == "main"
+}
+
+// DisplayName returns the name the package is presented with, that is its
+// path relative to the project or, for the root package, its import path
+func (p PackageInfo) DisplayName() string {
+	if p.Path == "." {
+		return p.ImportPath
+	}
+	return p.Path
+}
+
 // EntityInfo contains relevant information about each entity in the package
-// (functions, types, interfaces)
+// (functions, types, interfaces, constants, variables)
 type EntityInfo struct {
-	Name            string
-	Description     string
-	Example         string
-	Notes           string
-	DeprecationNote string
-	Parameters      []string
-	Returns         []string
-	Body            string
-	Type            string
-	Fields          []FieldInfo
-	Methods         []EntityInfo
-	Implements      []ImplementationInfo
-	Package         string
-	PackageURL      string
-	PackagePath     string
-	References      []ReferenceInfo
+	Name            string               `json:"name"`
+	Description     string               `json:"description,omitempty"`
+	Example         string               `json:"example,omitempty"`
+	Notes           string               `json:"notes,omitempty"`
+	DeprecationNote string               `json:"deprecationNote,omitempty"`
+	Comment         string               `json:"comment,omitempty"`
+	Signature       string               `json:"signature,omitempty"`
+	TypeParams      []string             `json:"typeParams,omitempty"`
+	Parameters      []string             `json:"parameters,omitempty"`
+	Returns         []string             `json:"returns,omitempty"`
+	Body            string               `json:"body,omitempty"`
+	Type            string               `json:"type"`
+	Fields          []FieldInfo          `json:"fields,omitempty"`
+	Values          []ValueInfo          `json:"values,omitempty"`
+	Methods         []EntityInfo         `json:"methods,omitempty"`
+	Embeds          []string             `json:"embeds,omitempty"`
+	TypeSet         []string             `json:"typeSet,omitempty"`
+	Receiver        string               `json:"receiver,omitempty"`
+	PromotedFrom    string               `json:"promotedFrom,omitempty"`
+	Implements      []ImplementationInfo `json:"implements,omitempty"`
+	Package         string               `json:"package,omitempty"`
+	PackageURL      string               `json:"packageURL,omitempty"`
+	PackagePath     string               `json:"packagePath,omitempty"`
+	References      []ReferenceInfo      `json:"references,omitempty"`
+	Examples        []ExampleInfo        `json:"examples,omitempty"`
+	Platforms       []string             `json:"platforms,omitempty"`
+	Location        *SourceLocation      `json:"location,omitempty"`
+	SourceURL       string               `json:"sourceURL,omitempty"`
 
 	// Raw fields
-	DescriptionRaw     string
-	DeprecationNoteRaw string
+	DescriptionRaw     string `json:"descriptionRaw,omitempty"`
+	DeprecationNoteRaw string `json:"deprecationNoteRaw,omitempty"`
+
+	// Object is the entity as resolved by the type checker
+	Object types.Object `json:"-"`
+
+	// exportedBody is the Body of a const or var block without the specs
+	// declaring unexported names only, as FilterVisibility documents it
+	exportedBody string
+}
+
+// SourceLocation is where an entity is declared, File is relative to the
+// project root and uses forward slashes. Entities without a declaration of
+// their own (e.g. interface methods) have no location
+type SourceLocation struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"endLine"`
+}
+
+// ExampleInfo contains information about a testable example, Name is the
+// documented entity (empty for the package) and Suffix the optional
+// lowercase suffix distinguishing multiple examples of the same entity
+type ExampleInfo struct {
+	Name        string `json:"name"`
+	Suffix      string `json:"suffix,omitempty"`
+	Doc         string `json:"doc,omitempty"`
+	Code        string `json:"code"`
+	Output      string `json:"output,omitempty"`
+	EmptyOutput bool   `json:"emptyOutput,omitempty"`
+	Unordered   bool   `json:"unordered,omitempty"`
 }
 
 // ReferenceInfo contains information about references used by an entity
 type ReferenceInfo struct {
-	Name        string
-	Package     string
-	PackageURL  string
-	PackagePath string
+	Name        string `json:"name"`
+	Package     string `json:"package,omitempty"`
+	PackageURL  string `json:"packageURL,omitempty"`
+	PackagePath string `json:"packagePath"`
 }
 
 // FieldInfo contains relevant information about each field in a struct
 type FieldInfo struct {
-	Name string
-	Type string
-	Tag  string
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Tag      string `json:"tag,omitempty"`
+	Embedded bool   `json:"embedded,omitempty"`
+	Doc      string `json:"doc,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// ValueInfo contains relevant information about each name declared in a
+// const or var block
+type ValueInfo struct {
+	Object   types.Object `json:"-"`
+	Name     string       `json:"name"`
+	Type     string       `json:"type,omitempty"`
+	Value    string       `json:"value,omitempty"`
+	Implicit bool         `json:"implicit,omitempty"`
+	Doc      string       `json:"doc,omitempty"`
+	Comment  string       `json:"comment,omitempty"`
 }
 
-// ImplementationInfo contains information about an implemented interface
+// ImplementationInfo contains information about an implemented interface,
+// PackageURL is only set for interfaces declared in the project
 type ImplementationInfo struct {
-	InterfaceName string
-	Package       string
+	InterfaceName string `json:"interfaceName"`
+	Package       string `json:"package,omitempty"`
+	PackageURL    string `json:"packageURL,omitempty"`
+	PackagePath   string `json:"packagePath"`
 }
 
 // ImportInfo contains information about an imported package
 type ImportInfo struct {
-	URL     string
-	Path    string
-	Alias   string
-	Doc     string
-	Comment string
+	URL     string `json:"url,omitempty"`
+	Path    string `json:"path"`
+	Alias   string `json:"alias,omitempty"`
+	Doc     string `json:"doc,omitempty"`
+	Comment string `json:"comment,omitempty"`
 }
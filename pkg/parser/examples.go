@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// outputPrefix matches the output comment of an example, as go test does
+var outputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// ParseExamples parses the testable examples (Example, ExampleFoo,
+// ExampleBar_Method, ...) declared in the _test.go files of a package, only
+// the files matching the build constraints of the target are considered
+//
+// Example:
+//
+//	examples, err := parser.ParseExamples(pkg, parser.BuildTarget{})
+//	if err != nil {
+//		log.Fatalf("Error parsing examples: %v", err)
+//	}
+//	pkgInfo = parser.AttachExamples(pkgInfo, examples)
+func ParseExamples(pkg *packages.Package, target BuildTarget) ([]ExampleInfo, error) {
+	dir := PackageDir(pkg)
+	if dir == "" {
+		return nil, nil
+	}
+
+	testFiles, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing test files: %v", err)
+	}
+
+	ctx := target.buildContext()
+	fs := token.NewFileSet()
+
+	var files []*ast.File
+	for _, testFile := range testFiles {
+		match, err := ctx.MatchFile(dir, filepath.Base(testFile))
+		if err != nil {
+			return nil, fmt.Errorf("error matching test file %s: %v", testFile, err)
+		}
+		if !match {
+			continue
+		}
+
+		file, err := parser.ParseFile(fs, testFile, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing test file %s: %v", testFile, err)
+		}
+
+		// both the package and its external test package are allowed
+		if file.Name.Name != pkg.Name && file.Name.Name != pkg.Name+"_test" {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	var examples []ExampleInfo
+	for _, example := range doc.Examples(files...) {
+		name, suffix := splitExampleName(example.Name)
+		examples = append(examples, ExampleInfo{
+			Name:        name,
+			Suffix:      suffix,
+			Doc:         strings.TrimSpace(example.Doc),
+			Code:        formatExampleCode(fs, example),
+			Output:      example.Output,
+			EmptyOutput: example.EmptyOutput,
+			Unordered:   example.Unordered,
+		})
+	}
+
+	sort.SliceStable(examples, func(i, j int) bool {
+		if examples[i].Name != examples[j].Name {
+			return examples[i].Name < examples[j].Name
+		}
+		return examples[i].Suffix < examples[j].Suffix
+	})
+
+	return examples, nil
+}
+
+// AttachExamples attaches the examples to the package (Example), to the
+// functions and types (ExampleFoo) and to the methods (ExampleBar_Method)
+// they document, examples not matching any entity are dropped
+func AttachExamples(pkgInfo PackageInfo, examples []ExampleInfo) PackageInfo {
+	for _, example := range examples {
+		if example.Name == "" {
+			pkgInfo.Examples = append(pkgInfo.Examples, example)
+			continue
+		}
+
+		typeName, methodName, isMethod := strings.Cut(example.Name, "_")
+		for i, entity := range pkgInfo.Entities {
+			if !isMethod {
+				if entity.Name == example.Name && entity.Type != "const" && entity.Type != "var" {
+					pkgInfo.Entities[i].Examples = append(pkgInfo.Entities[i].Examples, example)
+				}
+				continue
+			}
+
+			if entity.Name != typeName {
+				continue
+			}
+			for j, method := range entity.Methods {
+				if method.Name == methodName && method.PromotedFrom == "" {
+					pkgInfo.Entities[i].Methods[j].Examples = append(pkgInfo.Entities[i].Methods[j].Examples, example)
+				}
+			}
+		}
+	}
+
+	return pkgInfo
+}
+
+// splitExampleName splits the name of an example into the documented entity
+// and the suffix, e.g. Reader_Read_buffered into Reader_Read and buffered.
+// Suffixes start with a lowercase letter
+func splitExampleName(name string) (string, string) {
+	i := strings.LastIndex(name, "_")
+	if i < 0 || i == len(name)-1 {
+		return name, ""
+	}
+
+	suffix := name[i+1:]
+	if r, _ := utf8.DecodeRuneInString(suffix); unicode.IsUpper(r) {
+		return name, ""
+	}
+	return name[:i], suffix
+}
+
+// buildContext returns the go/build context matching the target, used to
+// evaluate the build constraints of the files go/packages does not load
+func (t BuildTarget) buildContext() build.Context {
+	ctx := build.Default
+	if t.GOOS != "" {
+		ctx.GOOS = t.GOOS
+	}
+	if t.GOARCH != "" {
+		ctx.GOARCH = t.GOARCH
+	}
+	ctx.BuildTags = t.Tags
+
+	// cgo is disabled when cross-compiling, as the go command does
+	if ctx.GOOS != runtime.GOOS || ctx.GOARCH != runtime.GOARCH {
+		ctx.CgoEnabled = false
+	}
+
+	return ctx
+}
+
+// formatExampleCode formats the body of an example function, without the
+// enclosing braces, or the whole file for whole file examples
+func formatExampleCode(fs *token.FileSet, example *doc.Example) string {
+	// the output comment is rendered on its own
+	var comments []*ast.CommentGroup
+	for _, comment := range example.Comments {
+		if !outputPrefix.MatchString(comment.Text()) {
+			comments = append(comments, comment)
+		}
+	}
+
+	var buf bytes.Buffer
+	node := &printer.CommentedNode{Node: example.Code, Comments: comments}
+	if err := format.Node(&buf, fs, node); err != nil {
+		return ""
+	}
+	code := buf.String()
+
+	if _, ok := example.Code.(*ast.BlockStmt); ok {
+		code = strings.TrimSpace(code)
+		code = strings.TrimPrefix(code, "{")
+		code = strings.TrimSuffix(code, "}")
+
+		var lines []string
+		for _, line := range strings.Split(strings.Trim(code, "\n"), "\n") {
+			lines = append(lines, strings.TrimPrefix(line, "\t"))
+		}
+		code = strings.Join(lines, "\n")
+	}
+
+	return code
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestSplitExampleName(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		suffix string
+	}{
+		{"", "", ""},
+		{"_second", "", "second"},
+		{"Foo", "Foo", ""},
+		{"Foo_buffered", "Foo", "buffered"},
+		{"Foo_Bar", "Foo_Bar", ""},
+		{"T_M_suffix", "T_M", "suffix"},
+		{"T_M_Suffix", "T_M_Suffix", ""},
+		{"Foo_", "Foo_", ""},
+		{"Foo_ünicode", "Foo", "ünicode"},
+	}
+
+	for _, test := range tests {
+		entity, suffix := splitExampleName(test.name)
+		if entity != test.entity || suffix != test.suffix {
+			t.Errorf("splitExampleName(%q) = %q, %q, want %q, %q", test.name, entity, suffix, test.entity, test.suffix)
+		}
+	}
+}
+
+func TestAttachExamples(t *testing.T) {
+	pkgInfo := PackageInfo{
+		Entities: []EntityInfo{
+			{Name: "Max", Type: "const"},
+			{Name: "New", Type: "function"},
+			{Name: "T", Type: "struct", Methods: []EntityInfo{
+				{Name: "M", Type: "method"},
+				{Name: "P", Type: "method", PromotedFrom: "Base"},
+			}},
+		},
+	}
+
+	pkgInfo = AttachExamples(pkgInfo, []ExampleInfo{
+		{Name: ""},
+		{Name: "", Suffix: "second"},
+		{Name: "Max"},
+		{Name: "New"},
+		{Name: "T"},
+		{Name: "T_M"},
+		{Name: "T_M", Suffix: "suffix"},
+		{Name: "T_P"},
+		{Name: "T_Missing"},
+		{Name: "Missing"},
+	})
+
+	tests := []struct {
+		name     string
+		examples []ExampleInfo
+		want     int
+	}{
+		{"package", pkgInfo.Examples, 2},
+		{"const Max", pkgInfo.Entities[0].Examples, 0},
+		{"function New", pkgInfo.Entities[1].Examples, 1},
+		{"type T", pkgInfo.Entities[2].Examples, 1},
+		{"method T.M", pkgInfo.Entities[2].Methods[0].Examples, 2},
+		{"promoted method T.P", pkgInfo.Entities[2].Methods[1].Examples, 0},
+	}
+
+	for _, test := range tests {
+		if len(test.examples) != test.want {
+			t.Errorf("%s has %d examples, want %d", test.name, len(test.examples), test.want)
+		}
+	}
+	if suffix := pkgInfo.Entities[2].Methods[0].Examples[1].Suffix; suffix != "suffix" {
+		t.Errorf("method T.M second example suffix = %q, want %q", suffix, "suffix")
+	}
+}
+
+func TestParseExamples(t *testing.T) {
+	_, pkgs := loadFixture(t, map[string]string{
+		"fx.go":           "package fx\n\n// T is a type\ntype T struct{}\n\n// M is a method\nfunc (T) M() {}\n",
+		"example_test.go": "package fx_test\n\nimport \"fmt\"\n\nfunc Example() {\n\tfmt.Println(\"package\")\n\t// Output: package\n}\n\nfunc ExampleT_M_suffix() {\n\tfmt.Println(\"method\")\n}\n",
+	})
+
+	examples, err := ParseExamples(pkgs[0], BuildTarget{})
+	if err != nil {
+		t.Fatalf("ParseExamples() error = %v", err)
+	}
+
+	want := []ExampleInfo{
+		{Name: "", Code: "fmt.Println(\"package\")", Output: "package\n"},
+		{Name: "T_M", Suffix: "suffix", Code: "fmt.Println(\"method\")"},
+	}
+	if len(examples) != len(want) {
+		t.Fatalf("ParseExamples() = %+v, want %+v", examples, want)
+	}
+	for i := range want {
+		if examples[i] != want[i] {
+			t.Errorf("example %d = %+v, want %+v", i, examples[i], want[i])
+		}
+	}
+}
@@ -3,33 +3,34 @@ package parser
 import (
 	"go/ast"
 	"go/token"
+	"go/types"
 )
 
-// EntityExtractor defines an interface for extracting information from AST declarations
+// EntityExtractor defines an interface for extracting information from AST
+// nodes, the node is a *ast.FuncDecl for functions and methods, a
+// *ast.TypeSpec for types and a *ast.GenDecl for const and var blocks
 type EntityExtractor interface {
-	Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo
+	Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo
 }
 
-// FunctionExtractor extracts information from function declarations
-type FunctionExtractor struct{}
-
-func (f FunctionExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo {
-	funcDecl := decl.(*ast.FuncDecl)
-	descriptionData := extractDescriptionData(funcDecl.Doc.Text())
+// newEntity returns an entity of the given type with the fields shared by
+// all the extractors: the object, the rendered documentation, the package
+// and the location of the node
+func newEntity(entityType string, obj types.Object, name string, node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	descriptionData := extractDescriptionData(doc.Text(), docs)
 
 	return EntityInfo{
-		Name:            funcDecl.Name.Name,
-		Type:            "function",
-		Body:            extractBody(fs, funcDecl),
+		Object:          obj,
+		Name:            name,
+		Type:            entityType,
 		Description:     descriptionData.Description,
 		Example:         descriptionData.Example,
 		Notes:           descriptionData.Notes,
 		DeprecationNote: descriptionData.DeprecationNote,
-		Parameters:      extractParameters(funcDecl.Type.Params),
-		Returns:         extractParameters(funcDecl.Type.Results),
 		Package:         pkgName,
 		PackageURL:      url,
 		PackagePath:     packagePath,
+		Location:        extractLocation(fs, node, packagePath),
 
 		// Raw fields
 		DescriptionRaw:     descriptionData.DescriptionRaw,
@@ -37,107 +38,107 @@ func (f FunctionExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces
 	}
 }
 
-// MethodExtractor extracts information from method declarations
-type MethodExtractor struct{}
+// FunctionExtractor extracts information from function declarations
+type FunctionExtractor struct{}
 
-func (m MethodExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo {
-	funcDecl := decl.(*ast.FuncDecl)
-	descriptionData := extractDescriptionData(funcDecl.Doc.Text())
+func (f FunctionExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	funcDecl := node.(*ast.FuncDecl)
 
-	return EntityInfo{
-		Name:            funcDecl.Name.Name,
-		Type:            "method",
-		Body:            extractBody(fs, funcDecl),
-		Description:     descriptionData.Description,
-		Example:         descriptionData.Example,
-		Notes:           descriptionData.Notes,
-		DeprecationNote: descriptionData.DeprecationNote,
-		Parameters:      extractParameters(funcDecl.Type.Params),
-		Returns:         extractParameters(funcDecl.Type.Results),
-		Package:         pkgName,
-		PackageURL:      url,
-		PackagePath:     packagePath,
+	entity := newEntity("function", info.Defs[funcDecl.Name], funcDecl.Name.Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Body = extractBody(fs, funcDecl)
+	entity.Signature = extractSignature(fs, funcDecl)
+	entity.TypeParams = extractParameters(funcDecl.Type.TypeParams)
+	entity.Parameters = extractParameters(funcDecl.Type.Params)
+	entity.Returns = extractParameters(funcDecl.Type.Results)
+	return entity
+}
 
-		// Raw fields
-		DescriptionRaw:     descriptionData.DescriptionRaw,
-		DeprecationNoteRaw: descriptionData.DeprecationNoteRaw,
-	}
+// MethodExtractor extracts information from method declarations
+type MethodExtractor struct{}
+
+func (m MethodExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	funcDecl := node.(*ast.FuncDecl)
+	obj := info.Defs[funcDecl.Name]
+
+	entity := newEntity("method", obj, funcDecl.Name.Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Receiver = receiverTypeName(obj)
+	entity.Body = extractBody(fs, funcDecl)
+	entity.Signature = extractSignature(fs, funcDecl)
+	entity.TypeParams = extractParameters(funcDecl.Type.TypeParams)
+	entity.Parameters = extractParameters(funcDecl.Type.Params)
+	entity.Returns = extractParameters(funcDecl.Type.Results)
+	return entity
 }
 
 // StructExtractor extracts information from struct declarations
 type StructExtractor struct{}
 
-func (s StructExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo {
-	spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+func (s StructExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	spec := node.(*ast.TypeSpec)
 	structType := spec.Type.(*ast.StructType)
 
-	descriptionData := extractDescriptionData(decl.(*ast.GenDecl).Doc.Text())
-
-	return EntityInfo{
-		Name:            spec.Name.Name,
-		Type:            "struct",
-		Description:     descriptionData.Description,
-		Notes:           descriptionData.Notes,
-		DeprecationNote: descriptionData.DeprecationNote,
-		Fields:          extractFields(structType),
-		Package:         pkgName,
-		PackageURL:      url,
-		PackagePath:     packagePath,
-
-		// Raw fields
-		DescriptionRaw:     descriptionData.DescriptionRaw,
-		DeprecationNoteRaw: descriptionData.DeprecationNoteRaw,
-	}
+	entity := newEntity("struct", info.Defs[spec.Name], spec.Name.Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Signature = extractTypeSignature(spec)
+	entity.TypeParams = extractParameters(spec.TypeParams)
+	entity.Fields = extractFields(structType)
+	return entity
 }
 
 // InterfaceExtractor extracts information from interface declarations
 type InterfaceExtractor struct{}
 
-func (i InterfaceExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo {
-	spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+func (i InterfaceExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	spec := node.(*ast.TypeSpec)
 	interfaceType := spec.Type.(*ast.InterfaceType)
-
-	descriptionData := extractDescriptionData(decl.(*ast.GenDecl).Doc.Text())
-
-	return EntityInfo{
-		Name:            spec.Name.Name,
-		Description:     descriptionData.Description,
-		Notes:           descriptionData.Notes,
-		DeprecationNote: descriptionData.DeprecationNote,
-		Type:            "interface",
-		Methods:         extractMethods(interfaceType),
-		Package:         pkgName,
-		PackageURL:      url,
-		PackagePath:     packagePath,
-
-		// Raw fields
-		DescriptionRaw:     descriptionData.DescriptionRaw,
-		DeprecationNoteRaw: descriptionData.DeprecationNoteRaw,
+	obj := info.Defs[spec.Name]
+
+	entity := newEntity("interface", obj, spec.Name.Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Signature = extractTypeSignature(spec)
+	entity.TypeParams = extractParameters(spec.TypeParams)
+	entity.Methods = extractMethods(interfaceType, info, docs, spec.Name.Name)
+	entity.Embeds = extractEmbeds(interfaceType, info)
+	if iface, ok := info.TypeOf(interfaceType).(*types.Interface); ok && obj != nil {
+		entity.TypeSet = extractTypeSet(iface, packageQualifier(obj.Pkg()))
 	}
+	return entity
 }
 
 // TypeExtractor extracts information from type declarations
 type TypeExtractor struct{}
 
-func (t TypeExtractor) Extract(decl ast.Decl, fs *token.FileSet, interfaces map[string]EntityInfo, pkgName string, packagePath string, url string) EntityInfo {
-	spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
-	typeExpr := formatExpr(spec.Type)
+func (t TypeExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	spec := node.(*ast.TypeSpec)
 
-	descriptionData := extractDescriptionData(decl.(*ast.GenDecl).Doc.Text())
+	entity := newEntity("type", info.Defs[spec.Name], spec.Name.Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Signature = extractTypeSignature(spec)
+	entity.TypeParams = extractParameters(spec.TypeParams)
+	entity.Body = formatExpr(spec.Type)
+	return entity
+}
 
-	return EntityInfo{
-		Name:            spec.Name.Name,
-		Description:     descriptionData.Description,
-		Notes:           descriptionData.Notes,
-		DeprecationNote: descriptionData.DeprecationNote,
-		Type:            "type",
-		Body:            typeExpr,
-		Package:         pkgName,
-		PackageURL:      url,
-		PackagePath:     packagePath,
+// ConstExtractor extracts information from const declarations
+type ConstExtractor struct{}
 
-		// Raw fields
-		DescriptionRaw:     descriptionData.DescriptionRaw,
-		DeprecationNoteRaw: descriptionData.DeprecationNoteRaw,
-	}
+func (c ConstExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	return extractValueDecl("const", node, doc, fs, info, docs, pkgName, packagePath, url)
+}
+
+// VarExtractor extracts information from var declarations
+type VarExtractor struct{}
+
+func (v VarExtractor) Extract(node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	return extractValueDecl("var", node, doc, fs, info, docs, pkgName, packagePath, url)
+}
+
+// extractValueDecl extracts a const or var block, named after its first
+// value
+func extractValueDecl(entityType string, node ast.Node, doc *ast.CommentGroup, fs *token.FileSet, info *types.Info, docs *DocRenderer, pkgName string, packagePath string, url string) EntityInfo {
+	genDecl := node.(*ast.GenDecl)
+	values := extractValues(genDecl, info)
+
+	entity := newEntity(entityType, values[0].Object, values[0].Name, node, doc, fs, docs, pkgName, packagePath, url)
+	entity.Body = extractDecl(fs, genDecl)
+	entity.exportedBody = extractExportedDecl(fs, genDecl)
+	entity.Values = values
+	return entity
 }
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadFixture writes the files of a fixture module, named example.com/fx,
+// to a temporary directory and loads its packages as GetPackages does
+func loadFixture(t *testing.T, files map[string]string) (string, []*packages.Package) {
+	t.Helper()
+
+	dir := writeFixture(t, files)
+	pkgs, err := GetPackages(BuildTarget{}, false)
+	if err != nil {
+		t.Fatalf("GetPackages() error = %v", err)
+	}
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			t.Fatalf("package %s: %v", pkg.PkgPath, pkgErr)
+		}
+	}
+
+	return dir, pkgs
+}
+
+// writeFixture writes the files of a fixture module to a temporary
+// directory, which becomes the current one for the rest of the test
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/fx\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the fixture is a module of its own, the flags of the go command
+	// running the tests do not apply to it
+	t.Setenv("GOFLAGS", "")
+
+	// GetPackages loads the packages of the current directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	return dir
+}
+
+// parseFixture loads a fixture module and parses its packages as Pallas
+// does, keyed by their path relative to the module
+func parseFixture(t *testing.T, files map[string]string, visibility Visibility) map[string]PackageInfo {
+	t.Helper()
+
+	dir, pkgs := loadFixture(t, files)
+	interfaces, err := CollectInterfaces(pkgs, nil)
+	if err != nil {
+		t.Fatalf("CollectInterfaces() error = %v", err)
+	}
+	resolver := NewLinkResolver(dir, pkgs, DefaultExternalDocsURL)
+
+	parsed := make(map[string]PackageInfo)
+	for _, pkg := range pkgs {
+		relativePath, err := filepath.Rel(dir, PackageDir(pkg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgInfo, err := ParsePackage(dir, pkg, relativePath, interfaces, resolver)
+		if err != nil {
+			t.Fatalf("ParsePackage(%s) error = %v", relativePath, err)
+		}
+		parsed[filepath.ToSlash(relativePath)] = FilterVisibility(pkgInfo, visibility)
+	}
+	return parsed
+}
+
+// findEntity returns the entity of a package with the given name
+func findEntity(t *testing.T, pkgInfo PackageInfo, name string) EntityInfo {
+	t.Helper()
+
+	for _, entity := range pkgInfo.Entities {
+		if entity.Name == name {
+			return entity
+		}
+	}
+	t.Fatalf("entity %s not found in package %s", name, pkgInfo.Path)
+	return EntityInfo{}
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"html"
+	"strings"
+)
+
+// HighlightGo highlights a Go snippet at generation time, the returned
+// HTML wraps keywords, strings, numbers, comments and predeclared
+// identifiers in spans with the hl-* classes. Snippets do not need to be
+// valid Go, unknown tokens are left as they are
+//
+// Example:
+//
+//	html := parser.HighlightGo("func Hello() string")
+func HighlightGo(code string) string {
+	lines := highlightSource([]byte(code), nil, nil)
+	return strings.Join(lines, "\n")
+}
+
+// highlightSource splits a Go source file in lines of highlighted HTML,
+// tokens spanning multiple lines (comments, raw strings) are split so that
+// each line is well formed
+func highlightSource(content []byte, objects map[int]types.Object, link func(types.Object) string) []string {
+	fs := token.NewFileSet()
+	file := fs.AddFile("", fs.Base(), len(content))
+
+	var s scanner.Scanner
+	s.Init(file, content, nil, scanner.ScanComments)
+
+	var out strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// automatically inserted semicolons are not part of the source
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		offset := file.Offset(pos)
+		if offset < last {
+			continue
+		}
+		// the text is taken from the source, as the scanner strips the
+		// carriage returns from raw strings and comments
+		text := tok.String()
+		if lit != "" {
+			text = lit
+		}
+		text = string(content[offset : offset+sourceLength(content[offset:], text)])
+
+		// snippets have no type information, so identifiers are looked up
+		// among the predeclared ones only
+		obj := objects[offset]
+		if objects == nil && tok == token.IDENT {
+			obj = types.Universe.Lookup(text)
+		}
+
+		out.WriteString(html.EscapeString(string(content[last:offset])))
+		out.WriteString(highlightToken(tok, text, obj, link))
+		last = offset + len(text)
+	}
+	out.WriteString(html.EscapeString(string(content[last:])))
+
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+// sourceLength returns the length in the source of a token whose text, as
+// returned by the scanner, is text: carriage returns in the source may be
+// missing from it
+func sourceLength(source []byte, text string) int {
+	i, j := 0, 0
+	for i < len(source) && j < len(text) {
+		switch {
+		case source[i] == text[j]:
+			j++
+		case source[i] != '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// highlightToken returns the HTML of a single token
+func highlightToken(tok token.Token, text string, obj types.Object, link func(types.Object) string) string {
+	class := ""
+	switch {
+	case tok == token.COMMENT:
+		class = "hl-comment"
+	case tok == token.STRING || tok == token.CHAR:
+		class = "hl-string"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		class = "hl-number"
+	case tok.IsKeyword():
+		class = "hl-keyword"
+	case tok == token.IDENT && obj != nil && obj.Parent() == types.Universe:
+		class = "hl-builtin"
+	}
+
+	escaped := html.EscapeString(text)
+	if tok == token.IDENT && obj != nil && link != nil {
+		if url := link(obj); url != "" {
+			return `<a href="` + html.EscapeString(url) + `" class="hl-link">` + escaped + `</a>`
+		}
+	}
+	if class == "" {
+		return escaped
+	}
+
+	// spans are closed and reopened on each line
+	open := `<span class="` + class + `">`
+	return open + strings.ReplaceAll(escaped, "\n", "</span>\n"+open) + "</span>"
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// tags matches the HTML tags added by the highlighter
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// plainText returns the text of highlighted lines, without the HTML
+func plainText(lines []string) string {
+	return html.UnescapeString(tags.ReplaceAllString(strings.Join(lines, "\n"), ""))
+}
+
+func TestHighlightSourceCRLF(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"raw string", "package p\r\n\r\nvar s = `line1\r\nline2`\r\n\r\nvar t = 1\r\n"},
+		{"block comment", "package p\r\n\r\n/* comment\r\n * more\r\n */\r\nvar t = 1\r\n"},
+		{"line comment", "package p // comment\r\n\r\nvar t = 1\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := highlightSource([]byte(test.source), nil, nil)
+
+			want := strings.TrimSuffix(test.source, "\n")
+			if got := plainText(lines); got != want {
+				t.Errorf("highlighted text = %q, want %q", got, want)
+			}
+			if got, want := len(lines), strings.Count(test.source, "\n"); got != want {
+				t.Errorf("got %d lines, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestHighlightGo(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "keywords and builtins",
+			code: "func Len(s string) int",
+			want: []string{`<span class="hl-keyword">func</span>`, `<span class="hl-builtin">string</span>`, `<span class="hl-builtin">int</span>`},
+		},
+		{
+			name: "literals",
+			code: `x := "a<b" + 'c' + 42`,
+			want: []string{`<span class="hl-string">&#34;a&lt;b&#34;</span>`, `<span class="hl-string">&#39;c&#39;</span>`, `<span class="hl-number">42</span>`},
+		},
+		{
+			name: "multi-line comment",
+			code: "/* one\ntwo */",
+			want: []string{"<span class=\"hl-comment\">/* one</span>\n<span class=\"hl-comment\">two */</span>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := HighlightGo(test.code)
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("HighlightGo(%q) = %q, want it to contain %q", test.code, got, want)
+				}
+			}
+			if text := plainText([]string{got}); text != test.code {
+				t.Errorf("HighlightGo(%q) text = %q", test.code, text)
+			}
+		})
+	}
+}
+
+func TestHighlightGoCRLF(t *testing.T) {
+	for _, code := range []string{
+		"s := `line1\r\nline2`\r\nx := 1",
+		"/* comment\r\n */\r\nx := 1",
+	} {
+		got := HighlightGo(code)
+		if text := plainText([]string{got}); text != code {
+			t.Errorf("HighlightGo(%q) text = %q", code, text)
+		}
+		if strings.Count(got, "line2") > 1 || strings.Count(got, "*/") > 1 {
+			t.Errorf("HighlightGo(%q) = %q, duplicates the token", code, got)
+		}
+	}
+}
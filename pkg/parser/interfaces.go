@@ -0,0 +1,241 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultStdInterfaces is the set of standard library interfaces checked by
+// default when looking for implementations
+var DefaultStdInterfaces = []string{
+	"error",
+	"fmt.Stringer",
+	"io.Reader",
+	"io.Writer",
+	"io.Closer",
+	"sort.Interface",
+}
+
+// CollectInterfaces returns the interfaces declared in all the project
+// packages, plus the given standard library (or any other importable)
+// interfaces, so that implementations are detected project-wide
+//
+// Example:
+//
+//	interfaces, err := parser.CollectInterfaces(pkgs, []string{"io.Reader", "fmt.Stringer"})
+//	if err != nil {
+//		log.Fatalf("Error collecting interfaces: %v", err)
+//	}
+//
+// Notes:
+// Extra interfaces are written as importpath.Name (e.g. encoding/json.Marshaler),
+// predeclared interfaces such as error are written by name only. Packages
+// which are not imported by the project are loaded on demand. Extra
+// interfaces which can not be resolved are skipped and reported in the
+// returned error, along with the interfaces which could be collected
+func CollectInterfaces(pkgs []*packages.Package, extraInterfaces []string) ([]*types.TypeName, error) {
+	var interfaces []*types.TypeName
+	for _, pkg := range pkgs {
+		interfaces = append(interfaces, collectInterfaces(pkg.Types)...)
+	}
+
+	if len(extraInterfaces) == 0 {
+		return interfaces, nil
+	}
+
+	// the extra interfaces are looked up in the packages the project imports
+	// first, so that they share the type identity with the project ones
+	imported := collectImportedPackages(pkgs)
+
+	var fs *token.FileSet
+	if len(pkgs) > 0 {
+		fs = pkgs[0].Fset
+	}
+
+	var unresolved []error
+	for _, extra := range extraInterfaces {
+		extra = strings.TrimSpace(extra)
+		if extra == "" {
+			continue
+		}
+
+		dot := strings.LastIndex(extra, ".")
+		if dot < 0 {
+			// predeclared interfaces, e.g. error
+			typeName, ok := types.Universe.Lookup(extra).(*types.TypeName)
+			if !ok || !types.IsInterface(typeName.Type()) {
+				unresolved = append(unresolved, fmt.Errorf("%s is not a predeclared interface, skipping", extra))
+				continue
+			}
+			interfaces = append(interfaces, typeName)
+			continue
+		}
+
+		pkgPath, name := extra[:dot], extra[dot+1:]
+		var typeName *types.TypeName
+		if pkg, ok := imported[pkgPath]; ok {
+			typeName, _ = pkg.Scope().Lookup(name).(*types.TypeName)
+		}
+		if typeName == nil {
+			loaded, err := loadTypes(pkgPath, fs)
+			if err != nil {
+				unresolved = append(unresolved, fmt.Errorf("%v, skipping %s", err, extra))
+				continue
+			}
+			imported[pkgPath] = loaded
+			typeName, _ = loaded.Scope().Lookup(name).(*types.TypeName)
+		}
+
+		if typeName == nil || !types.IsInterface(typeName.Type()) {
+			unresolved = append(unresolved, fmt.Errorf("%s is not an interface, skipping", extra))
+			continue
+		}
+		interfaces = append(interfaces, typeName)
+	}
+
+	return interfaces, errors.Join(unresolved...)
+}
+
+// collectImportedPackages returns the project packages and the packages
+// they import directly. Packages only reached through other imports are
+// left out: they are loaded from export data, which only holds the part of
+// them their importers refer to
+func collectImportedPackages(pkgs []*packages.Package) map[string]*types.Package {
+	imported := make(map[string]*types.Package)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		imported[pkg.PkgPath] = pkg.Types
+		for _, imp := range pkg.Types.Imports() {
+			if imp.Complete() {
+				imported[imp.Path()] = imp
+			}
+		}
+	}
+	return imported
+}
+
+// loadTypes loads the type information of a single package
+func loadTypes(pkgPath string, fs *token.FileSet) (*types.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes,
+		Fset: fs,
+	}
+
+	pkgs, err := packages.Load(cfg, pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 || pkgs[0].Types == nil || len(pkgs[0].Errors) > 0 {
+		return nil, fmt.Errorf("error loading package %s", pkgPath)
+	}
+
+	return pkgs[0].Types, nil
+}
+
+// collectInterfaces returns the interfaces declared in a package which can
+// be implemented by other types, sorted by name. Empty interfaces and
+// constraint interfaces (type sets) are left out since they are either
+// implemented by everything or by nothing
+func collectInterfaces(pkg *types.Package) []*types.TypeName {
+	var interfaces []*types.TypeName
+	if pkg == nil {
+		return interfaces
+	}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+
+		iface, ok := typeName.Type().Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 || !iface.IsMethodSet() {
+			continue
+		}
+
+		if named, ok := typeName.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+			continue
+		}
+
+		interfaces = append(interfaces, typeName)
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Name() < interfaces[j].Name()
+	})
+
+	return interfaces
+}
+
+// findImplementedInterfaces checks which interfaces are implemented by a
+// type, interfaces declared in the project are linked to their page
+func findImplementedInterfaces(entity EntityInfo, interfaces []*types.TypeName, fs *token.FileSet, projectPath string) []ImplementationInfo {
+	var implemented []ImplementationInfo
+
+	typeName, ok := entity.Object.(*types.TypeName)
+	if !ok {
+		return implemented
+	}
+
+	for _, iface := range interfaces {
+		if iface == typeName {
+			continue
+		}
+
+		if !implementsInterface(typeName.Type(), iface.Type()) {
+			continue
+		}
+
+		implementation := ImplementationInfo{
+			InterfaceName: iface.Name(),
+			Package:       "builtin",
+			PackagePath:   "builtin",
+		}
+		if iface.Pkg() != nil {
+			implementation.Package = iface.Pkg().Name()
+			implementation.PackagePath = iface.Pkg().Path()
+			if relativePath, ok := projectRelativePath(iface, fs, projectPath); ok {
+				implementation.PackagePath = relativePath
+				implementation.PackageURL = packageURL(relativePath)
+			}
+		}
+
+		implemented = append(implemented, implementation)
+	}
+
+	sort.SliceStable(implemented, func(i, j int) bool {
+		if implemented[i].PackagePath != implemented[j].PackagePath {
+			return implemented[i].PackagePath < implemented[j].PackagePath
+		}
+		return implemented[i].InterfaceName < implemented[j].InterfaceName
+	})
+
+	return implemented
+}
+
+// implementsInterface checks if a type, or a pointer to it, implements a
+// given interface according to the type checker, which takes into account
+// the full method set of the interface (embedded interfaces included) and
+// the methods promoted to the type through embedded fields
+func implementsInterface(typ types.Type, iface types.Type) bool {
+	ifaceType, ok := iface.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+
+	// generic types can not be checked without instantiating them
+	if named, ok := typ.(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return false
+	}
+
+	return types.Implements(typ, ifaceType) || types.Implements(types.NewPointer(typ), ifaceType)
+}
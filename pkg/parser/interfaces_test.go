@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestCollectInterfacesIndirectImports(t *testing.T) {
+	// fmt refers to io, but io is not imported by the project, so the
+	// importer only knows the part of io that fmt uses
+	_, pkgs := loadFixture(t, map[string]string{
+		"fx.go": "package fx\n\nimport \"fmt\"\n\n// Hello prints hello\nfunc Hello() { fmt.Println(\"hello\") }\n",
+	})
+
+	interfaces, err := CollectInterfaces(pkgs, DefaultStdInterfaces)
+	if err != nil {
+		t.Fatalf("CollectInterfaces() error = %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, iface := range interfaces {
+		name := iface.Name()
+		if iface.Pkg() != nil {
+			name = iface.Pkg().Path() + "." + name
+		}
+		found[name] = true
+	}
+	for _, want := range DefaultStdInterfaces {
+		if !found[want] {
+			t.Errorf("interface %s not collected, got %v", want, found)
+		}
+	}
+}
+
+func TestCollectInterfacesUnresolved(t *testing.T) {
+	_, pkgs := loadFixture(t, map[string]string{
+		"fx.go": "package fx\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n",
+	})
+
+	interfaces, err := CollectInterfaces(pkgs, []string{"fmt.Stringer", "fmt.Sprint", "example.com/missing.Iface", "nothing"})
+	if err == nil {
+		t.Error("CollectInterfaces() error = nil, want the unresolved interfaces")
+	}
+	if len(interfaces) != 1 || interfaces[0].Name() != "Stringer" {
+		t.Errorf("CollectInterfaces() = %v, want only fmt.Stringer", interfaces)
+	}
+}
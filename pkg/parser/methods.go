@@ -0,0 +1,267 @@
+package parser
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// findPromotedMethods returns the methods promoted to a struct through its
+// embedded fields, as resolved by the type checker. Methods declared in the
+// same package keep their documentation, the others are documented by
+// their signature and linked to the declaring type when it is part of the
+// project
+func findPromotedMethods(entity EntityInfo, methodsByType map[string][]EntityInfo, pkg *types.Package, fs *token.FileSet, projectPath string) []EntityInfo {
+	var promoted []EntityInfo
+
+	typeName, ok := entity.Object.(*types.TypeName)
+	if !ok {
+		return promoted
+	}
+
+	// the method set of the pointer type is a superset of the value one
+	methodSet := types.NewMethodSet(types.NewPointer(typeName.Type()))
+	for i := 0; i < methodSet.Len(); i++ {
+		selection := methodSet.At(i)
+		if len(selection.Index()) < 2 {
+			continue
+		}
+
+		fn, ok := selection.Obj().(*types.Func)
+		if !ok {
+			continue
+		}
+
+		method := EntityInfo{
+			Name:         fn.Name(),
+			Type:         "method",
+			Receiver:     receiverTypeName(fn),
+			PromotedFrom: embeddingPath(typeName.Type(), selection.Index()),
+			Object:       fn,
+		}
+
+		// reuse the documentation when the method is declared in this package
+		if fn.Pkg() == pkg {
+			for _, declared := range methodsByType[method.Receiver] {
+				if declared.Name == fn.Name() {
+					method = declared
+					method.PromotedFrom = embeddingPath(typeName.Type(), selection.Index())
+					break
+				}
+			}
+		}
+
+		if method.Signature == "" {
+			signature := fn.Type().(*types.Signature)
+			qualifier := packageQualifier(pkg)
+			method.Signature = formatSignature(fn, qualifier)
+			method.Parameters = formatTuple(signature.Params(), signature.Variadic(), qualifier)
+			method.Returns = formatTuple(signature.Results(), false, qualifier)
+		}
+
+		if fn.Pkg() != nil {
+			method.Package = fn.Pkg().Name()
+			if relativePath, ok := projectRelativePath(fn, fs, projectPath); ok {
+				method.PackagePath = relativePath
+				method.PackageURL = packageURL(relativePath)
+			}
+		}
+
+		promoted = append(promoted, method)
+	}
+
+	return promoted
+}
+
+// findEmbeddedMethods returns the methods an interface gets from the
+// interfaces it embeds, so that its full method set is documented. Methods
+// of interfaces declared in the same package keep their documentation
+func findEmbeddedMethods(entity EntityInfo, interfacesByName map[string]EntityInfo, pkg *types.Package, fs *token.FileSet, projectPath string) []EntityInfo {
+	var embedded []EntityInfo
+
+	typeName, ok := entity.Object.(*types.TypeName)
+	if !ok {
+		return embedded
+	}
+	iface, ok := typeName.Type().Underlying().(*types.Interface)
+	if !ok {
+		return embedded
+	}
+
+	explicit := make(map[*types.Func]bool)
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		explicit[iface.ExplicitMethod(i)] = true
+	}
+
+	qualifier := packageQualifier(pkg)
+	for i := 0; i < iface.NumMethods(); i++ {
+		fn := iface.Method(i)
+		if explicit[fn] {
+			continue
+		}
+
+		method := EntityInfo{
+			Name:         fn.Name(),
+			Type:         "method",
+			Receiver:     receiverTypeName(fn),
+			PromotedFrom: embeddingInterface(iface, fn, qualifier),
+			Object:       fn,
+		}
+
+		// reuse the documentation when the interface is declared in this package
+		if fn.Pkg() == pkg {
+			for _, declared := range interfacesByName[method.Receiver].Methods {
+				if declared.Name == fn.Name() && declared.PromotedFrom == "" {
+					method = declared
+					method.PromotedFrom = embeddingInterface(iface, fn, qualifier)
+					break
+				}
+			}
+		}
+
+		if method.Parameters == nil && method.Returns == nil {
+			signature := fn.Type().(*types.Signature)
+			method.Parameters = formatTuple(signature.Params(), signature.Variadic(), qualifier)
+			method.Returns = formatTuple(signature.Results(), false, qualifier)
+		}
+
+		if fn.Pkg() != nil {
+			method.Package = fn.Pkg().Name()
+			if relativePath, ok := projectRelativePath(fn, fs, projectPath); ok {
+				method.PackagePath = relativePath
+				method.PackageURL = packageURL(relativePath)
+			}
+		}
+
+		embedded = append(embedded, method)
+	}
+
+	return embedded
+}
+
+// embeddingInterface returns the embedded interface a method comes from
+func embeddingInterface(iface *types.Interface, fn *types.Func, qualifier types.Qualifier) string {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		embeddedType := iface.EmbeddedType(i)
+		embeddedIface, ok := embeddedType.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for j := 0; j < embeddedIface.NumMethods(); j++ {
+			if embeddedIface.Method(j) == fn {
+				return types.TypeString(embeddedType, qualifier)
+			}
+		}
+	}
+	return ""
+}
+
+// embeddingPath returns the names of the embedded fields a method is
+// promoted through, e.g. Base or Base.Inner
+func embeddingPath(typ types.Type, index []int) string {
+	var path []string
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		structType, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+		field := structType.Field(i)
+		path = append(path, field.Name())
+		typ = field.Type()
+	}
+	return strings.Join(path, ".")
+}
+
+// formatSignature formats the signature of a method as declared, including
+// its receiver
+func formatSignature(fn *types.Func, qualifier types.Qualifier) string {
+	var recvType string
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		recvType = types.TypeString(recv.Type(), qualifier)
+	}
+	return formatReceiverSignature(fn, recvType, qualifier)
+}
+
+// formatReceiverSignature formats the signature of a method with the given
+// receiver type, which is left out when empty
+func formatReceiverSignature(fn *types.Func, recvType string, qualifier types.Qualifier) string {
+	signature := fn.Type().(*types.Signature)
+
+	var out strings.Builder
+	out.WriteString("func ")
+	if recvType != "" {
+		out.WriteString("(")
+		if recv := signature.Recv(); recv != nil && recv.Name() != "" {
+			out.WriteString(recv.Name() + " ")
+		}
+		out.WriteString(recvType)
+		out.WriteString(") ")
+	}
+	out.WriteString(fn.Name())
+
+	// the type string of a signature starts with "func"
+	out.WriteString(strings.TrimPrefix(types.TypeString(signature, qualifier), "func"))
+
+	return out.String()
+}
+
+// ownMethod documents a method promoted through an unexported embedded type
+// as declared by the embedding type, as go doc does, since there is no
+// documentation to link the embedded type to
+func ownMethod(entity EntityInfo, method EntityInfo) EntityInfo {
+	fn, ok := method.Object.(*types.Func)
+	if ok && method.Signature != "" && entity.Object != nil {
+		recvType := entity.Name
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			if _, ok := recv.Type().(*types.Pointer); ok {
+				recvType = "*" + recvType
+			}
+		}
+		method.Signature = formatReceiverSignature(fn, recvType, packageQualifier(entity.Object.Pkg()))
+	}
+
+	method.Receiver = entity.Name
+	method.PromotedFrom = ""
+	method.Package = entity.Package
+	method.PackagePath = entity.PackagePath
+	method.PackageURL = entity.PackageURL
+	return method
+}
+
+// formatTuple formats the parameters or results of a signature the same way
+// extractParameters does for declarations
+func formatTuple(tuple *types.Tuple, variadic bool, qualifier types.Qualifier) []string {
+	var params []string
+	for i := 0; i < tuple.Len(); i++ {
+		param := tuple.At(i)
+
+		typeStr := types.TypeString(param.Type(), qualifier)
+		if variadic && i == tuple.Len()-1 {
+			if slice, ok := param.Type().(*types.Slice); ok {
+				typeStr = "..." + types.TypeString(slice.Elem(), qualifier)
+			}
+		}
+
+		if param.Name() != "" {
+			params = append(params, param.Name()+" "+typeStr)
+		} else {
+			params = append(params, typeStr)
+		}
+	}
+	return params
+}
+
+// packageQualifier qualifies the types of other packages by their package
+// name, as they read in the source and in go doc, while the types of pkg
+// are not qualified
+func packageQualifier(pkg *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+}
@@ -1,50 +1,153 @@
 package parser
 
 import (
+	"go/ast"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
-// GetPackages returns a list of all package directories in the project
+// loadMode is the information loaded for each package, syntax and type
+// information are loaded in a single pass so that the parser can rely on
+// the type checker
+const loadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedSyntax |
+	packages.NeedTypes |
+	packages.NeedTypesInfo
+
+// GetPackages returns all the packages in the project, loaded with their
+// syntax and type information for the given build target, so that only the
+// files matching its build constraints are documented. The _test.go files
+// are not part of the API and are left out, unless includeTests is set to
+// document the declarations of the tests too (e.g. test helpers)
 //
 // Example:
 //
-//	packages, err := parser.GetPackages()
+//	packages, err := parser.GetPackages(parser.BuildTarget{GOOS: "linux", GOARCH: "amd64"}, false)
 //	if err != nil {
 //		log.Fatalf("Error fetching packages: %v", err)
 //	}
 //	for _, pkg := range packages {
-//		fmt.Printf("Package: %s\n", pkg)
+//		fmt.Printf("Package: %s\n", pkg.PkgPath)
 //	}
-func GetPackages() ([]string, error) {
+func GetPackages(target BuildTarget, includeTests bool) ([]*packages.Package, error) {
 	cfg := &packages.Config{
-		Mode: packages.NeedFiles, // We only need the file paths
+		Mode:       loadMode,
+		Tests:      includeTests,
+		Env:        target.env(),
+		BuildFlags: target.buildFlags(),
 	}
 
-	rootDir, err := filepath.Abs(".")
+	pkgs, err := packages.Load(cfg, "./...")
 	if err != nil {
 		return nil, err
 	}
 
-	pkgs, err := packages.Load(cfg, "./...")
-	if err != nil {
-		return nil, err
+	if includeTests {
+		pkgs = testVariants(pkgs)
 	}
 
-	var packageDirs []string
+	var projectPackages []*packages.Package
 	for _, pkg := range pkgs {
 		if len(pkg.GoFiles) > 0 {
-			// To get the package directory, we take the directory of the first Go file
-			dir := filepath.Dir(pkg.GoFiles[0])
-
-			// if the directory is the root of the project, we skip it
-			if dir == rootDir {
-				continue
-			}
-			packageDirs = append(packageDirs, dir)
+			projectPackages = append(projectPackages, pkg)
+		}
+	}
+
+	// packages are sorted by import path so that the output is the same on
+	// every run
+	sort.Slice(projectPackages, func(i, j int) bool {
+		return projectPackages[i].PkgPath < projectPackages[j].PkgPath
+	})
+
+	return projectPackages, nil
+}
+
+// testVariants replaces the packages with their variant compiled for tests,
+// which includes their _test.go files. The external test packages (e.g.
+// foo_test) and the generated test executables are left out, they can not
+// be imported, as are the other variants of the packages recompiled for the
+// tests of another one
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	// the variant of foo with its tests is identified as "foo [foo.test]"
+	variants := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath+" ["+pkg.PkgPath+".test]" {
+			variants[pkg.PkgPath] = pkg
+		}
+	}
+
+	var selected []*packages.Package
+	for _, pkg := range pkgs {
+		if strings.Contains(pkg.ID, " [") || strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		if variant, ok := variants[pkg.PkgPath]; ok {
+			pkg = variant
+		}
+		selected = append(selected, pkg)
+	}
+	return selected
+}
+
+// PackageDir returns the directory of a loaded package
+func PackageDir(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return ""
+	}
+
+	// To get the package directory, we take the directory of the first Go file
+	return filepath.Dir(pkg.GoFiles[0])
+}
+
+// packageFiles returns the syntax trees of a package sorted by file name.
+// Files declaring a different package than the one chosen by go/packages
+// (e.g. a stray main in the same directory) are left out
+func packageFiles(pkg *packages.Package) []*ast.File {
+	var files []*ast.File
+	for _, file := range pkg.Syntax {
+		if file.Name.Name == pkg.Name {
+			files = append(files, file)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return pkg.Fset.Position(files[i].Package).Filename < pkg.Fset.Position(files[j].Package).Filename
+	})
+
+	return files
+}
+
+// ForeignFiles returns the files of a package directory which declare a
+// different package than the documented one, these are not documented
+//
+// Example:
+//
+//	for _, file := range parser.ForeignFiles(pkg) {
+//		fmt.Printf("Warning: %s is not part of package %s\n", file, pkg.Name)
+//	}
+//
+// Notes:
+// External test packages (foo_test) are not reported since _test.go files
+// are never part of the documented API
+func ForeignFiles(pkg *packages.Package) []string {
+	var files []string
+	for _, file := range pkg.Syntax {
+		if file.Name.Name == pkg.Name {
+			continue
+		}
+
+		fileName := pkg.Fset.Position(file.Package).Filename
+		if strings.HasSuffix(fileName, "_test.go") {
+			continue
 		}
+		files = append(files, fileName)
 	}
 
-	return packageDirs, nil
+	sort.Strings(files)
+	return files
 }
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetPackagesTests(t *testing.T) {
+	writeFixture(t, map[string]string{
+		"fx.go":           "package fx\n\n// Run runs\nfunc Run() {}\n",
+		"helper_test.go":  "package fx\n\n// Helper helps the tests\nfunc Helper() {}\n",
+		"example_test.go": "package fx_test\n\nimport \"example.com/fx\"\n\nfunc ExampleRun() { fx.Run() }\n",
+	})
+
+	tests := []struct {
+		includeTests bool
+		files        []string
+	}{
+		{false, []string{"fx.go"}},
+		{true, []string{"fx.go", "helper_test.go"}},
+	}
+
+	for _, test := range tests {
+		pkgs, err := GetPackages(BuildTarget{}, test.includeTests)
+		if err != nil {
+			t.Fatalf("GetPackages() error = %v", err)
+		}
+		if len(pkgs) != 1 || pkgs[0].PkgPath != "example.com/fx" {
+			for _, pkg := range pkgs {
+				t.Logf("loaded %s", pkg.ID)
+			}
+			t.Fatalf("GetPackages(%v) returned %d packages, want example.com/fx only", test.includeTests, len(pkgs))
+		}
+
+		var files []string
+		for _, file := range pkgs[0].GoFiles {
+			files = append(files, filepath.Base(file))
+		}
+		if len(files) != len(test.files) {
+			t.Errorf("GetPackages(%v) files = %v, want %v", test.includeTests, files, test.files)
+			continue
+		}
+		for i := range files {
+			if files[i] != test.files[i] {
+				t.Errorf("GetPackages(%v) files = %v, want %v", test.includeTests, files, test.files)
+				break
+			}
+		}
+	}
+}
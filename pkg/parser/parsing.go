@@ -1,19 +1,89 @@
 package parser
 
 import (
+	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/doc"
 	"go/token"
-	"os"
+	"go/types"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
+// ParsePackage parses a package, its doc comment and its entities
+//
+// Example:
+//
+//	pkgInfo, err := parser.ParsePackage("/home/me/myproject", pkgs[0], "pkg/mypackage", interfaces, resolver)
+//	if err != nil {
+//		log.Fatalf("Error parsing package: %v", err)
+//	}
+//	fmt.Printf("%s: %s\n", pkgInfo.Name, pkgInfo.Synopsis)
+//
+// Notes:
+// See ParseEntitiesInPackage for the requirements on the arguments
+func ParsePackage(projectPath string, pkg *packages.Package, relativePath string, interfaces []*types.TypeName, resolver *LinkResolver) (PackageInfo, error) {
+	entities, imports, err := ParseEntitiesInPackage(projectPath, pkg, relativePath, interfaces, resolver)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	docRaw := extractPackageDoc(pkg)
+
+	var files []string
+	for _, file := range packageFiles(pkg) {
+		fileName := pkg.Fset.Position(file.Package).Filename
+		files = append(files, filepath.ToSlash(filepath.Join(relativePath, filepath.Base(fileName))))
+	}
+
+	var synopsis doc.Package
+	return PackageInfo{
+		Name:       pkg.Name,
+		ImportPath: pkg.PkgPath,
+		Path:       relativePath,
+		URL:        packageURL(relativePath),
+		Doc:        resolver.ForPackage(pkg).Render(docRaw),
+		Synopsis:   synopsis.Synopsis(docRaw),
+		Entities:   entities,
+		Imports:    imports,
+		Files:      files,
+
+		// Raw fields
+		DocRaw: docRaw,
+	}, nil
+}
+
+// extractPackageDoc extracts the package doc comment, the "// Package foo"
+// comment which is usually placed in a doc.go file. If more files have a
+// package comment, they are all collected as go/doc does
+func extractPackageDoc(pkg *packages.Package) string {
+	var docs []string
+	for _, file := range packageFiles(pkg) {
+		if file.Doc != nil {
+			docs = append(docs, file.Doc.Text())
+		}
+	}
+	return strings.Join(docs, "\n")
+}
+
 // ParseEntitiesInPackage parses the entities in a given package and returns
 // a slice of EntityInfo
 //
 // Example:
 //
-//	entities, err := parser.ParseEntitiesInPackage("/home/me/myproject/pkg/mypackage")
+//	pkgs, err := parser.GetPackages(parser.BuildTarget{}, false)
+//	if err != nil {
+//		log.Fatalf("Error fetching packages: %v", err)
+//	}
+//	interfaces, err := parser.CollectInterfaces(pkgs, []string{"io.Reader"})
+//	if err != nil {
+//		log.Fatalf("Error collecting interfaces: %v", err)
+//	}
+//	resolver := parser.NewLinkResolver("/home/me/myproject", pkgs, parser.DefaultExternalDocsURL)
+//	entities, imports, err := parser.ParseEntitiesInPackage("/home/me/myproject", pkgs[0], "pkg/mypackage", interfaces, resolver)
 //	if err != nil {
 //		log.Fatalf("Error parsing entities: %v", err)
 //	}
@@ -25,27 +95,23 @@ import (
 //	}
 //
 // Notes:
-// The package must be a full path to the package directory
-func ParseEntitiesInPackage(projectPath string, pkgPath string, relativePath string) ([]EntityInfo, []ImportInfo, error) {
+// The package must be loaded by GetPackages so that its syntax and type
+// information are available, the project path must be a full path. The
+// interfaces are the ones checked for implementations, as returned by
+// CollectInterfaces, while the resolver is used for the doc links
+func ParseEntitiesInPackage(projectPath string, pkg *packages.Package, relativePath string, interfaces []*types.TypeName, resolver *LinkResolver) ([]EntityInfo, []ImportInfo, error) {
 	var entities []EntityInfo
 	var imports []ImportInfo
-	var interfaces = make(map[string]EntityInfo)
 	var methodsByType = make(map[string][]EntityInfo)
-	var entityIndex = make(map[string]EntityInfo)
 
-	fs := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fs, pkgPath, nil, parser.ParseComments)
-	if err != nil {
-		return nil, nil, err
+	if len(packageFiles(pkg)) == 0 || pkg.TypesInfo == nil {
+		return nil, nil, fmt.Errorf("no syntax or type information loaded for package %s", pkg.PkgPath)
 	}
 
-	var pkgName string
-	var pkg *ast.Package
-	for k, v := range pkgs {
-		pkgName = k
-		pkg = v
-		break
-	}
+	fs := pkg.Fset
+	info := pkg.TypesInfo
+	docs := resolver.ForPackage(pkg)
+	pkgName := pkg.Name
 
 	extractors := map[string]EntityExtractor{
 		"function":  FunctionExtractor{},
@@ -53,13 +119,13 @@ func ParseEntitiesInPackage(projectPath string, pkgPath string, relativePath str
 		"struct":    StructExtractor{},
 		"interface": InterfaceExtractor{},
 		"type":      TypeExtractor{},
+		"const":     ConstExtractor{},
+		"var":       VarExtractor{},
 	}
 
-	// Replace slashes with hyphens to ensure unique filenames
-	url := strings.ReplaceAll(relativePath, string(os.PathSeparator), "-")
-
-	for _, file := range pkg.Files {
+	url := packageURL(relativePath)
 
+	for _, file := range packageFiles(pkg) {
 		// here we parse all imports
 		for _, imp := range file.Imports {
 			importPath := strings.Trim(imp.Path.Value, `"`)
@@ -99,130 +165,97 @@ func ParseEntitiesInPackage(projectPath string, pkgPath string, relativePath str
 			switch decl := decl.(type) {
 			case *ast.FuncDecl:
 				if decl.Recv != nil {
-					receiverType := formatExpr(decl.Recv.List[0].Type)
-					method := extractors["method"].Extract(decl, fs, interfaces, pkgName, relativePath, url)
-					methodsByType[receiverType] = append(methodsByType[receiverType], method)
+					method := extractors["method"].Extract(decl, decl.Doc, fs, info, docs, pkgName, relativePath, url)
+					methodsByType[method.Receiver] = append(methodsByType[method.Receiver], method)
 				} else {
-					entity := extractors["function"].Extract(decl, fs, interfaces, pkgName, relativePath, url)
+					entity := extractors["function"].Extract(decl, decl.Doc, fs, info, docs, pkgName, relativePath, url)
 					entities = append(entities, entity)
-					entityIndex[pkgName+"."+entity.Name] = entity
 				}
 			case *ast.GenDecl:
+				// const and var blocks are documented as a whole, so that
+				// groups (e.g. iota enumerations) are kept together
+				if decl.Tok == token.CONST || decl.Tok == token.VAR {
+					if len(extractValues(decl, info)) == 0 {
+						continue
+					}
+
+					entity := extractors[decl.Tok.String()].Extract(decl, decl.Doc, fs, info, docs, pkgName, relativePath, url)
+					entities = append(entities, entity)
+					continue
+				}
+
 				for _, spec := range decl.Specs {
 					switch spec := spec.(type) {
 					case *ast.TypeSpec:
+						// in grouped declarations each spec has its own doc
+						// comment, otherwise we fall back to the group one
+						doc := spec.Doc
+						if doc == nil {
+							doc = decl.Doc
+						}
+
 						var entityType string
 						switch spec.Type.(type) {
 						case *ast.StructType:
 							entityType = "struct"
 						case *ast.InterfaceType:
 							entityType = "interface"
-							if _, exists := interfaces[spec.Name.Name]; !exists {
-								ifaceInfo := extractors[entityType].Extract(decl, fs, interfaces, pkgName, relativePath, url)
-								ifaceInfo.Package = pkgName
-								interfaces[spec.Name.Name] = ifaceInfo
-								entities = append(entities, ifaceInfo)
-								entityIndex[pkgName+"."+ifaceInfo.Name] = ifaceInfo
-							}
 						default:
 							entityType = "type"
 						}
 
-						if entityType != "interface" {
-							entity := extractors[entityType].Extract(decl, fs, interfaces, pkgName, relativePath, url)
-							entities = append(entities, entity)
-							entityIndex[pkgName+"."+entity.Name] = entity
-						}
+						entity := extractors[entityType].Extract(spec, doc, fs, info, docs, pkgName, relativePath, url)
+						entities = append(entities, entity)
 					}
 				}
 			}
 		}
 	}
 
-	// Here we associate methods with structs, resolve interfaces
-	// implementations and find references for each entity
+	// imports are sorted by path, so that the output does not depend on the
+	// order of the files
+	sort.SliceStable(imports, func(i, j int) bool {
+		return imports[i].Path < imports[j].Path
+	})
+
+	interfacesByName := make(map[string]EntityInfo)
+	for _, entity := range entities {
+		if entity.Type == "interface" {
+			interfacesByName[entity.Name] = entity
+		}
+	}
+
+	// Here we associate methods with named types, expand embedded interfaces,
+	// resolve interfaces implementations and find references for each entity
 	for i, entity := range entities {
-		references := findReferences(entity, entityIndex)
+		references := findReferences(entity, fs, projectPath)
 		entity.References = references
 
-		// if the entity is a struct, we associate methods with it
-		if entity.Type == "struct" {
-			receiverName := entity.Name
-			if methods, ok := methodsByType[receiverName]; ok {
-				entity.Methods = append(entity.Methods, methods...)
-			} else if methods, ok := methodsByType["*"+receiverName]; ok {
+		// if the entity is a named type (structs included), we associate
+		// methods with it
+		if entity.Type == "struct" || entity.Type == "type" {
+			if methods, ok := methodsByType[entity.Name]; ok {
 				entity.Methods = append(entity.Methods, methods...)
 			}
+			entity.Methods = append(entity.Methods, findPromotedMethods(entity, methodsByType, pkg.Types, fs, projectPath)...)
 
-			entity.Implements = findImplementedInterfaces(entity, interfaces)
+			entity.Implements = findImplementedInterfaces(entity, interfaces, fs, projectPath)
 
 			// and here we find references for each method if any
 			for j, method := range entity.Methods {
-				methodReferences := findReferences(method, entityIndex)
+				methodReferences := findReferences(method, fs, projectPath)
 				entity.Methods[j].References = methodReferences
 			}
 		}
 
-		entities[i] = entity
-	}
-
-	return entities, imports, nil
-}
-
-// findImplementedInterfaces checks which interfaces are implemented by a struct
-func findImplementedInterfaces(entity EntityInfo, interfaces map[string]EntityInfo) []ImplementationInfo {
-	var implemented []ImplementationInfo
-
-	for ifaceName, ifaceInfo := range interfaces {
-		if implementsInterface(entity, ifaceInfo) {
-			implemented = append(implemented, ImplementationInfo{
-				InterfaceName: ifaceName,
-				Package:       ifaceInfo.Package,
-			})
-		}
-	}
-
-	return implemented
-}
-
-// implementsInterface checks if a struct implements a given interface
-func implementsInterface(entity EntityInfo, iface EntityInfo) bool {
-	methodSet := make(map[string]EntityInfo)
-	for _, method := range entity.Methods {
-		methodSet[method.Name] = method
-	}
-
-	for _, ifaceMethod := range iface.Methods {
-		if method, ok := methodSet[ifaceMethod.Name]; !ok {
-			return false
-		} else {
-			if !methodsMatch(ifaceMethod, method) {
-				return false
-			}
-		}
-	}
-
-	return true
-}
-
-// methodsMatch checks if the parameters and return types of two methods match
-func methodsMatch(ifaceMethod, structMethod EntityInfo) bool {
-	if len(ifaceMethod.Parameters) != len(structMethod.Parameters) ||
-		len(ifaceMethod.Returns) != len(structMethod.Returns) {
-		return false
-	}
-
-	for i, param := range ifaceMethod.Parameters {
-		if param != structMethod.Parameters[i] {
-			return false
+		// interfaces are documented with their full method set, including
+		// the methods of the embedded interfaces
+		if entity.Type == "interface" {
+			entity.Methods = append(entity.Methods, findEmbeddedMethods(entity, interfacesByName, pkg.Types, fs, projectPath)...)
 		}
-	}
 
-	for i, ret := range ifaceMethod.Returns {
-		if ret != structMethod.Returns[i] {
-			return false
-		}
+		entities[i] = entity
 	}
 
-	return true
+	return entities, imports, nil
 }
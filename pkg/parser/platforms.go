@@ -0,0 +1,272 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BuildTarget is the platform (GOOS/GOARCH) and the build tags the packages
+// are loaded for, empty fields fall back to the ones of the host
+type BuildTarget struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// String returns the platform of the target in the GOOS/GOARCH form
+func (t BuildTarget) String() string {
+	return t.GOOS + "/" + t.GOARCH
+}
+
+// env returns the environment go/packages is run with, nil means the
+// environment of the current process
+func (t BuildTarget) env() []string {
+	if t.GOOS == "" && t.GOARCH == "" {
+		return nil
+	}
+
+	env := os.Environ()
+	if t.GOOS != "" {
+		env = append(env, "GOOS="+t.GOOS)
+	}
+	if t.GOARCH != "" {
+		env = append(env, "GOARCH="+t.GOARCH)
+	}
+	return env
+}
+
+// buildFlags returns the build flags go/packages is run with
+func (t BuildTarget) buildFlags() []string {
+	if len(t.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(t.Tags, ",")}
+}
+
+// ParsePlatforms parses a comma-separated list of GOOS/GOARCH pairs into
+// build targets sharing the given build tags
+//
+// Example:
+//
+//	targets, err := parser.ParsePlatforms("linux/amd64,windows/amd64", []string{"netgo"})
+//	if err != nil {
+//		log.Fatalf("Error parsing platforms: %v", err)
+//	}
+func ParsePlatforms(platforms string, tags []string) ([]BuildTarget, error) {
+	var targets []BuildTarget
+	seen := make(map[string]bool)
+	for _, platform := range strings.Split(platforms, ",") {
+		platform = strings.TrimSpace(platform)
+		if platform == "" {
+			continue
+		}
+
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid platform %q, expected GOOS/GOARCH", platform)
+		}
+		if seen[platform] {
+			continue
+		}
+		seen[platform] = true
+
+		targets = append(targets, BuildTarget{GOOS: goos, GOARCH: goarch, Tags: tags})
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no platform in %q", platforms)
+	}
+	return targets, nil
+}
+
+// MergePlatforms merges the packages parsed for each build target into a
+// single view, entities (and methods) which are not available on every
+// platform are labeled with the platforms they are available on
+//
+// Example:
+//
+//	merged := parser.MergePlatforms(targets, [][]parser.PackageInfo{linuxPkgs, windowsPkgs})
+//
+// Notes:
+// Entities are matched by kind and name, the documentation of the first
+// platform declaring an entity is the one kept
+func MergePlatforms(targets []BuildTarget, parsed [][]PackageInfo) []PackageInfo {
+	var platforms []string
+	for _, target := range targets {
+		platforms = append(platforms, target.String())
+	}
+
+	var merged []PackageInfo
+	indexes := make(map[string]int)
+	packagePlatforms := make(map[string][]string)
+	entityPlatforms := make(map[string]map[string][]string)
+
+	for i, pkgs := range parsed {
+		platform := platforms[i]
+		for _, pkg := range pkgs {
+			index, ok := indexes[pkg.ImportPath]
+			if !ok {
+				index = len(merged)
+				indexes[pkg.ImportPath] = index
+				merged = append(merged, pkg)
+				entityPlatforms[pkg.ImportPath] = make(map[string][]string)
+				merged[index].Entities = nil
+				merged[index].Imports = nil
+				merged[index].Files = nil
+				merged[index].Sources = nil
+			}
+			packagePlatforms[pkg.ImportPath] = append(packagePlatforms[pkg.ImportPath], platform)
+
+			merged[index].Entities = mergeEntities(merged[index].Entities, pkg.Entities, platform, "", entityPlatforms[pkg.ImportPath])
+			merged[index].Imports = mergeImports(merged[index].Imports, pkg.Imports)
+			merged[index].Files = mergeFiles(merged[index].Files, pkg.Files)
+			merged[index].Sources = mergeSources(merged[index].Sources, pkg.Sources)
+		}
+	}
+
+	for i, pkg := range merged {
+		merged[i].Platforms = platformLabels(packagePlatforms[pkg.ImportPath], platforms)
+		labelEntities(merged[i].Entities, "", entityPlatforms[pkg.ImportPath], packagePlatforms[pkg.ImportPath])
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ImportPath < merged[j].ImportPath
+	})
+
+	return merged
+}
+
+// mergeEntities appends to a merged list the entities of a platform which
+// are not part of it yet, recording the platforms each entity is found on
+func mergeEntities(merged []EntityInfo, entities []EntityInfo, platform string, prefix string, found map[string][]string) []EntityInfo {
+	keys := entityKeys(merged, prefix)
+	positions := make(map[string]int)
+	for i, key := range keys {
+		positions[key] = i
+	}
+
+	for i, key := range entityKeys(entities, prefix) {
+		found[key] = append(found[key], platform)
+
+		position, ok := positions[key]
+		if !ok {
+			entity := entities[i]
+			entity.Methods = mergeEntities(nil, entity.Methods, platform, key+".", found)
+
+			positions[key] = len(merged)
+			merged = append(merged, entity)
+			continue
+		}
+
+		// methods may be declared in platform-specific files, so the method
+		// lists are merged as well
+		merged[position].Methods = mergeEntities(merged[position].Methods, entities[i].Methods, platform, key+".", found)
+	}
+
+	return merged
+}
+
+// labelEntities sets the platforms of the entities which are not available
+// on every platform their package is available on
+func labelEntities(entities []EntityInfo, prefix string, found map[string][]string, packagePlatforms []string) {
+	for i, key := range entityKeys(entities, prefix) {
+		entities[i].Platforms = platformLabels(found[key], packagePlatforms)
+		labelEntities(entities[i].Methods, key+".", found, packagePlatforms)
+	}
+}
+
+// entityKeys returns the keys entities are matched by across platforms,
+// entities sharing kind and name (e.g. init functions) are numbered
+func entityKeys(entities []EntityInfo, prefix string) []string {
+	keys := make([]string, len(entities))
+	counts := make(map[string]int)
+	for i, entity := range entities {
+		key := prefix + entity.Type + ":" + entity.Name
+		keys[i] = fmt.Sprintf("%s#%d", key, counts[key])
+		counts[key]++
+	}
+	return keys
+}
+
+// platformLabels returns the platforms an entity is available on, or nil
+// when it is available on all of them
+func platformLabels(found []string, all []string) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, platform := range found {
+		if !seen[platform] {
+			seen[platform] = true
+			labels = append(labels, platform)
+		}
+	}
+
+	if len(labels) >= len(all) {
+		return nil
+	}
+	return labels
+}
+
+// mergeImports appends to a merged list the imports which are not part of
+// it yet
+func mergeImports(merged []ImportInfo, imports []ImportInfo) []ImportInfo {
+	seen := make(map[string]bool)
+	for _, imp := range merged {
+		seen[imp.Path+" "+imp.Alias] = true
+	}
+
+	for _, imp := range imports {
+		key := imp.Path + " " + imp.Alias
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, imp)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Path < merged[j].Path
+	})
+	return merged
+}
+
+// mergeFiles appends to a merged list the files which are not part of it
+// yet, platform-specific files are only found on some platforms
+func mergeFiles(merged []string, files []string) []string {
+	seen := make(map[string]bool)
+	for _, file := range merged {
+		seen[file] = true
+	}
+
+	for _, file := range files {
+		if !seen[file] {
+			seen[file] = true
+			merged = append(merged, file)
+		}
+	}
+
+	sort.Strings(merged)
+	return merged
+}
+
+// mergeSources appends to a merged list the source files which are not
+// part of it yet
+func mergeSources(merged []SourceFile, sources []SourceFile) []SourceFile {
+	seen := make(map[string]bool)
+	for _, source := range merged {
+		seen[source.Path] = true
+	}
+
+	for _, source := range sources {
+		if !seen[source.Path] {
+			seen[source.Path] = true
+			merged = append(merged, source)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Path < merged[j].Path
+	})
+	return merged
+}
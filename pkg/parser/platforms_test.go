@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePlatforms(t *testing.T) {
+	targets := []BuildTarget{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "windows", GOARCH: "amd64"}}
+	function := func(name string) EntityInfo {
+		return EntityInfo{Name: name, Type: "function"}
+	}
+	handle := func(methods ...string) EntityInfo {
+		entity := EntityInfo{Name: "Handle", Type: "struct"}
+		for _, method := range methods {
+			entity.Methods = append(entity.Methods, EntityInfo{Name: method, Type: "method"})
+		}
+		return entity
+	}
+
+	merged := MergePlatforms(targets, [][]PackageInfo{
+		{
+			{ImportPath: "example.com/fx", Entities: []EntityInfo{function("Open"), function("OpenLinux"), handle("Close", "Fd")}},
+			{ImportPath: "example.com/fx/unix", Entities: []EntityInfo{function("Uname")}},
+		},
+		{
+			{ImportPath: "example.com/fx", Entities: []EntityInfo{function("Open"), function("OpenWindows"), handle("Close")}},
+		},
+	})
+
+	if len(merged) != 2 {
+		t.Fatalf("MergePlatforms() returned %d packages, want 2", len(merged))
+	}
+
+	tests := []struct {
+		name      string
+		got       []string
+		platforms []string
+	}{
+		{"package fx", merged[0].Platforms, nil},
+		{"shared Open", merged[0].Entities[0].Platforms, nil},
+		{"OpenLinux", merged[0].Entities[1].Platforms, []string{"linux/amd64"}},
+		{"shared Handle", merged[0].Entities[2].Platforms, nil},
+		{"shared Handle.Close", merged[0].Entities[2].Methods[0].Platforms, nil},
+		{"Handle.Fd", merged[0].Entities[2].Methods[1].Platforms, []string{"linux/amd64"}},
+		{"OpenWindows", merged[0].Entities[3].Platforms, []string{"windows/amd64"}},
+		{"package fx/unix", merged[1].Platforms, []string{"linux/amd64"}},
+		// available on every platform of its package
+		{"Uname", merged[1].Entities[0].Platforms, nil},
+	}
+
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.platforms) {
+			t.Errorf("%s platforms = %q, want %q", test.name, test.got, test.platforms)
+		}
+	}
+
+	var names []string
+	for _, entity := range merged[0].Entities {
+		names = append(names, entity.Name)
+	}
+	if want := []string{"Open", "OpenLinux", "Handle", "OpenWindows"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("merged entities = %q, want %q", names, want)
+	}
+}
+
+func TestMergeEntities(t *testing.T) {
+	initFunc := EntityInfo{Name: "init", Type: "function"}
+	tests := []struct {
+		name     string
+		merged   []EntityInfo
+		entities []EntityInfo
+		want     []string
+		found    map[string][]string
+	}{
+		{
+			name:     "new entities are appended",
+			merged:   []EntityInfo{{Name: "A", Type: "function"}},
+			entities: []EntityInfo{{Name: "B", Type: "function"}},
+			want:     []string{"A", "B"},
+			found:    map[string][]string{"function:B#0": {"windows/amd64"}},
+		},
+		{
+			name:     "same name of another kind",
+			merged:   []EntityInfo{{Name: "A", Type: "function"}},
+			entities: []EntityInfo{{Name: "A", Type: "type"}},
+			want:     []string{"A", "A"},
+			found:    map[string][]string{"type:A#0": {"windows/amd64"}},
+		},
+		{
+			name:     "init functions are numbered",
+			merged:   []EntityInfo{initFunc, initFunc},
+			entities: []EntityInfo{initFunc},
+			want:     []string{"init", "init"},
+			found:    map[string][]string{"function:init#0": {"windows/amd64"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := make(map[string][]string)
+			merged := mergeEntities(test.merged, test.entities, "windows/amd64", "", found)
+
+			var names []string
+			for _, entity := range merged {
+				names = append(names, entity.Name)
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("mergeEntities() = %q, want %q", names, test.want)
+			}
+			if !reflect.DeepEqual(found, test.found) {
+				t.Errorf("found platforms = %v, want %v", found, test.found)
+			}
+		})
+	}
+}
+
+func TestPlatformLabels(t *testing.T) {
+	all := []string{"linux/amd64", "windows/amd64", "darwin/arm64"}
+	tests := []struct {
+		name  string
+		found []string
+		want  []string
+	}{
+		{"everywhere", all, nil},
+		{"some", []string{"linux/amd64", "darwin/arm64"}, []string{"linux/amd64", "darwin/arm64"}},
+		{"duplicates", []string{"linux/amd64", "linux/amd64"}, []string{"linux/amd64"}},
+		{"duplicates everywhere", []string{"linux/amd64", "windows/amd64", "windows/amd64", "darwin/arm64"}, nil},
+	}
+
+	for _, test := range tests {
+		if got := platformLabels(test.found, all); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: platformLabels(%q) = %q, want %q", test.name, test.found, got, test.want)
+		}
+	}
+}
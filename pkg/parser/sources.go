@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SourceFile is a Go source file of a package, ready to be rendered as a
+// page: each line is highlighted HTML where the identifiers of documented
+// entities link back to their documentation
+type SourceFile struct {
+	Path        string
+	PackageName string
+	PackageURL  string
+	Lines       []string
+}
+
+// SourcePageURL returns the path, relative to the output directory, of the
+// generated page of a source file
+func SourcePageURL(file string) string {
+	return "source/" + strings.ReplaceAll(file, "/", "-") + ".html"
+}
+
+// LinkSources sets the source link of the entities and methods of a
+// package. The sourceURL template can use the {path}, {line} and {endline}
+// placeholders, when it is empty the links point to the generated source
+// pages
+//
+// Example:
+//
+//	pkgInfo = parser.LinkSources(pkgInfo, "https://github.com/vanilla-os/pallas/blob/main/{path}#L{line}-L{endline}")
+func LinkSources(pkgInfo PackageInfo, sourceURL string) PackageInfo {
+	for i := range pkgInfo.Entities {
+		entity := &pkgInfo.Entities[i]
+		entity.SourceURL = formatSourceURL(entity.Location, sourceURL)
+		for j := range entity.Methods {
+			entity.Methods[j].SourceURL = formatSourceURL(entity.Methods[j].Location, sourceURL)
+		}
+	}
+	return pkgInfo
+}
+
+// formatSourceURL returns the source link of a location, or an empty string
+// if the location is unknown
+func formatSourceURL(location *SourceLocation, sourceURL string) string {
+	if location == nil {
+		return ""
+	}
+
+	if sourceURL == "" {
+		return SourcePageURL(location.File) + "#L" + strconv.Itoa(location.Line)
+	}
+
+	replacer := strings.NewReplacer(
+		"{path}", location.File,
+		"{line}", strconv.Itoa(location.Line),
+		"{endline}", strconv.Itoa(location.EndLine),
+	)
+	return replacer.Replace(sourceURL)
+}
+
+// ParseSourceFiles highlights the source files of a package and links the
+// identifiers of the documented entities to their documentation, with the
+// given visibility only the identifiers that are documented are linked
+//
+// Example:
+//
+//	sources, err := parser.ParseSourceFiles(pkg, "pkg/mypackage", resolver, parser.VisibilityExported)
+//	if err != nil {
+//		log.Fatalf("Error parsing source files: %v", err)
+//	}
+func ParseSourceFiles(pkg *packages.Package, relativePath string, resolver *LinkResolver, visibility Visibility) ([]SourceFile, error) {
+	var sources []SourceFile
+	for _, file := range packageFiles(pkg) {
+		fileName := pkg.Fset.Position(file.Package).Filename
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error reading source file %s: %v", fileName, err)
+		}
+
+		// identifiers are matched to the type checker objects by offset, uses
+		// come first as the identifier of an embedded field both defines the
+		// field and uses its type, which is the one to link
+		objects := make(map[int]types.Object)
+		ast.Inspect(file, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok {
+				obj := pkg.TypesInfo.Uses[ident]
+				if obj == nil {
+					obj = pkg.TypesInfo.Defs[ident]
+				}
+				if obj != nil {
+					objects[pkg.Fset.Position(ident.Pos()).Offset] = obj
+				}
+			}
+			return true
+		})
+
+		// the methods of unexported types are not documented either
+		link := func(obj types.Object) string {
+			if visibility != VisibilityAll {
+				recv := receiverTypeName(obj)
+				if !obj.Exported() || (recv != "" && !token.IsExported(recv)) {
+					return ""
+				}
+			}
+			return resolver.entityURL(obj, "../")
+		}
+
+		// the root package is named after its import path, as DisplayName does
+		name := filepath.ToSlash(relativePath)
+		if relativePath == "." {
+			name = pkg.PkgPath
+		}
+
+		sources = append(sources, SourceFile{
+			Path:        filepath.ToSlash(filepath.Join(relativePath, filepath.Base(fileName))),
+			PackageName: name,
+			PackageURL:  packageURL(relativePath),
+			Lines:       highlightSource(content, objects, link),
+		})
+	}
+
+	return sources, nil
+}
+
+// entityURL returns the documentation link of a package-level object or of
+// a method declared in the project, prefix is prepended to the page name
+func (r *LinkResolver) entityURL(obj types.Object, prefix string) string {
+	if obj.Pkg() == nil {
+		return ""
+	}
+
+	relativePath, ok := r.packagePaths[obj.Pkg().Path()]
+	if !ok {
+		return ""
+	}
+
+	anchor := obj.Name()
+	if recv := receiverTypeName(obj); recv != "" {
+		anchor = recv + "." + obj.Name()
+	} else if obj.Parent() != obj.Pkg().Scope() {
+		// locals, fields and methods of unnamed types are not documented
+		return ""
+	}
+
+	return prefix + packageURL(relativePath) + ".html#" + anchor
+}
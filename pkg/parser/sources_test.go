@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSourceFilesEmbeddedFields(t *testing.T) {
+	dir, pkgs := loadFixture(t, map[string]string{
+		"lib/lib.go": "package lib\n\n// Base is embedded\ntype Base struct{}\n",
+		"api/api.go": "package api\n\nimport \"example.com/fx/lib\"\n\n// Local is embedded\ntype Local struct{}\n\n// Outer embeds\ntype Outer struct {\n\tlib.Base\n\t*Local\n}\n",
+	})
+	resolver := NewLinkResolver(dir, pkgs, DefaultExternalDocsURL)
+
+	for _, pkg := range pkgs {
+		if pkg.Name != "api" {
+			continue
+		}
+
+		sources, err := ParseSourceFiles(pkg, "api", resolver, VisibilityExported)
+		if err != nil {
+			t.Fatalf("ParseSourceFiles() error = %v", err)
+		}
+		page := strings.Join(sources[0].Lines, "\n")
+
+		for _, want := range []string{
+			`lib.<a href="../lib.html#Base" class="hl-link">Base</a>`,
+			`*<a href="../api.html#Local" class="hl-link">Local</a>`,
+		} {
+			if !strings.Contains(page, want) {
+				t.Errorf("source page does not contain %q:\n%s", want, page)
+			}
+		}
+	}
+}
@@ -3,21 +3,38 @@ package parser
 import (
 	"go/ast"
 	"go/format"
+	"go/parser"
 	"go/token"
-	"html"
+	"go/types"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-// extractMethods extracts methods from an interface declaration
-func extractMethods(interfaceType *ast.InterfaceType) []EntityInfo {
+// extractMethods extracts the methods explicitly declared in an interface,
+// along with their doc and line comments
+func extractMethods(interfaceType *ast.InterfaceType, info *types.Info, docs *DocRenderer, interfaceName string) []EntityInfo {
 	var methods []EntityInfo
 	for _, field := range interfaceType.Methods.List {
 		if funcType, ok := field.Type.(*ast.FuncType); ok {
+			descriptionData := extractDescriptionData(field.Doc.Text(), docs)
+
 			methodInfo := EntityInfo{
-				Name:       field.Names[0].Name,
-				Parameters: extractParameters(funcType.Params),
-				Returns:    extractParameters(funcType.Results),
+				Object:          info.Defs[field.Names[0]],
+				Name:            field.Names[0].Name,
+				Type:            "method",
+				Receiver:        interfaceName,
+				Description:     descriptionData.Description,
+				Example:         descriptionData.Example,
+				Notes:           descriptionData.Notes,
+				DeprecationNote: descriptionData.DeprecationNote,
+				Comment:         strings.TrimSpace(field.Comment.Text()),
+				Parameters:      extractParameters(funcType.Params),
+				Returns:         extractParameters(funcType.Results),
+
+				// Raw fields
+				DescriptionRaw:     descriptionData.DescriptionRaw,
+				DeprecationNoteRaw: descriptionData.DeprecationNoteRaw,
 			}
 			methods = append(methods, methodInfo)
 		}
@@ -25,16 +42,138 @@ func extractMethods(interfaceType *ast.InterfaceType) []EntityInfo {
 	return methods
 }
 
-// extractFields extracts fields from a struct
+// extractEmbeds extracts the interfaces embedded in an interface, e.g.
+// io.Reader, the type set terms are extracted by extractTypeSet
+func extractEmbeds(interfaceType *ast.InterfaceType, info *types.Info) []string {
+	var embeds []string
+	for _, field := range interfaceType.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+
+		if typ := info.TypeOf(field.Type); typ != nil && types.IsInterface(typ) {
+			embeds = append(embeds, formatExpr(field.Type))
+		}
+	}
+	return embeds
+}
+
+// extractTypeSet extracts the type set of a constraint interface, one entry
+// per term (e.g. ~int and ~string), including the terms of the embedded
+// constraint interfaces. Interfaces whose type set is not restricted by
+// terms have none
+func extractTypeSet(iface *types.Interface, qualifier types.Qualifier) []string {
+	terms, _ := typeSetTerms(iface)
+
+	var typeSet []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		formatted := types.TypeString(term.Type(), qualifier)
+		if term.Tilde() {
+			formatted = "~" + formatted
+		}
+		if !seen[formatted] {
+			seen[formatted] = true
+			typeSet = append(typeSet, formatted)
+		}
+	}
+	return typeSet
+}
+
+// typeSetTerms returns the terms of the type set of a type, which is not
+// restricted by terms when false is returned (e.g. interfaces with methods
+// only, or comparable)
+func typeSetTerms(typ types.Type) ([]*types.Term, bool) {
+	if union, ok := typ.(*types.Union); ok {
+		var terms []*types.Term
+		for i := 0; i < union.Len(); i++ {
+			term := union.Term(i)
+			if !types.IsInterface(term.Type()) {
+				terms = append(terms, term)
+				continue
+			}
+
+			// a union with an unrestricted interface allows any type
+			embedded, restricted := typeSetTerms(term.Type().Underlying())
+			if !restricted {
+				return nil, false
+			}
+			terms = append(terms, embedded...)
+		}
+		return terms, true
+	}
+
+	iface, ok := typ.Underlying().(*types.Interface)
+	if !ok {
+		return []*types.Term{types.NewTerm(false, typ)}, true
+	}
+
+	// the type set of an interface is the intersection of the ones of its
+	// embedded elements
+	var terms []*types.Term
+	restricted := false
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		embedded, ok := typeSetTerms(iface.EmbeddedType(i))
+		if !ok {
+			continue
+		}
+		if restricted {
+			terms = intersectTerms(terms, embedded)
+		} else {
+			terms = embedded
+		}
+		restricted = true
+	}
+	return terms, restricted
+}
+
+// intersectTerms returns the terms allowed by both term lists
+func intersectTerms(a []*types.Term, b []*types.Term) []*types.Term {
+	var terms []*types.Term
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case types.Identical(x.Type(), y.Type()):
+				terms = append(terms, types.NewTerm(x.Tilde() && y.Tilde(), x.Type()))
+			case x.Tilde() && types.Identical(x.Type(), y.Type().Underlying()):
+				terms = append(terms, y)
+			case y.Tilde() && types.Identical(y.Type(), x.Type().Underlying()):
+				terms = append(terms, x)
+			}
+		}
+	}
+	return terms
+}
+
+// extractFields extracts fields from a struct, along with their doc and
+// line comments
 func extractFields(structType *ast.StructType) []FieldInfo {
 	var fields []FieldInfo
 	for _, field := range structType.Fields.List {
 		typeStr := formatExpr(field.Type)
+		doc := strings.TrimSpace(field.Doc.Text())
+		comment := strings.TrimSpace(field.Comment.Text())
+
+		// embedded fields are named after their type
+		if len(field.Names) == 0 {
+			fields = append(fields, FieldInfo{
+				Name:     embeddedFieldName(field.Type),
+				Type:     typeStr,
+				Tag:      extractTag(field),
+				Embedded: true,
+				Doc:      doc,
+				Comment:  comment,
+			})
+			continue
+		}
+
 		for _, name := range field.Names {
 			fieldInfo := FieldInfo{
-				Name: name.Name,
-				Type: typeStr,
-				Tag:  extractTag(field),
+				Name:    name.Name,
+				Type:    typeStr,
+				Tag:     extractTag(field),
+				Doc:     doc,
+				Comment: comment,
 			}
 			fields = append(fields, fieldInfo)
 		}
@@ -42,6 +181,280 @@ func extractFields(structType *ast.StructType) []FieldInfo {
 	return fields
 }
 
+// extractValues extracts the names declared in a const or var block, along
+// with their types and values
+//
+// Notes:
+// Constants without an explicit value repeat the type and the expression of
+// the previous spec (e.g. iota groups), such values are marked as implicit.
+// The constants of such blocks, and of the blocks using iota, all have the
+// value computed by the type checker, so that the block reads the same way
+func extractValues(genDecl *ast.GenDecl, info *types.Info) []ValueInfo {
+	var values []ValueInfo
+	var lastType string
+	var lastValues []ast.Expr
+	computed := genDecl.Tok == token.CONST && hasComputedValues(genDecl, info)
+
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+
+		typeStr := ""
+		if valueSpec.Type != nil {
+			typeStr = formatExpr(valueSpec.Type)
+		}
+
+		exprs := valueSpec.Values
+		implicit := false
+		if genDecl.Tok == token.CONST {
+			if len(exprs) == 0 {
+				exprs = lastValues
+				typeStr = lastType
+				implicit = true
+			} else {
+				lastValues = exprs
+				lastType = typeStr
+			}
+		}
+
+		doc := strings.TrimSpace(valueSpec.Doc.Text())
+		comment := strings.TrimSpace(valueSpec.Comment.Text())
+
+		for i, name := range valueSpec.Names {
+			// blank identifiers (e.g. interface assertions) are not worth
+			// documenting
+			if name.Name == "_" {
+				continue
+			}
+
+			value := ""
+			if len(exprs) == len(valueSpec.Names) {
+				value = formatExpr(exprs[i])
+			} else if len(exprs) > 0 {
+				// multiple names assigned from a single expression, e.g. a
+				// function call returning multiple values
+				var parts []string
+				for _, expr := range exprs {
+					parts = append(parts, formatExpr(expr))
+				}
+				value = strings.Join(parts, ", ")
+			}
+
+			obj := info.Defs[name]
+			if constObj, ok := obj.(*types.Const); ok && computed {
+				value = constObj.Val().ExactString()
+			}
+
+			values = append(values, ValueInfo{
+				Object:   obj,
+				Name:     name.Name,
+				Type:     typeStr,
+				Value:    value,
+				Implicit: implicit,
+				Doc:      doc,
+				Comment:  comment,
+			})
+		}
+	}
+	return values
+}
+
+// hasComputedValues reports whether a const block has implicit values or
+// uses iota, whose values only make sense once computed
+func hasComputedValues(genDecl *ast.GenDecl, info *types.Info) bool {
+	iotaObj := types.Universe.Lookup("iota")
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if len(valueSpec.Values) == 0 {
+			return true
+		}
+
+		usesIota := false
+		for _, expr := range valueSpec.Values {
+			ast.Inspect(expr, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && info.Uses[ident] == iotaObj {
+					usesIota = true
+				}
+				return !usesIota
+			})
+		}
+		if usesIota {
+			return true
+		}
+	}
+	return false
+}
+
+// extractDecl extracts the source of a declaration, formatted with the
+// go/format package
+func extractDecl(fs *token.FileSet, decl *ast.GenDecl) string {
+	// the doc comment is already part of the description, so we leave it out
+	// of the formatted source
+	declCopy := *decl
+	declCopy.Doc = nil
+
+	var out strings.Builder
+	if err := format.Node(&out, fs, &declCopy); err != nil {
+		return ""
+	}
+
+	return out.String()
+}
+
+// extractExportedDecl formats a const or var declaration without the specs
+// declaring unexported names only, the unexported names of the other specs
+// are replaced by _ so that names and values still match, as go doc does
+//
+// Notes:
+// Implicit constants following a spec which is left out get its type, as go
+// doc does, so that e.g. the constants of a typed iota block keep their type
+func extractExportedDecl(fs *token.FileSet, decl *ast.GenDecl) string {
+	declCopy := *decl
+	declCopy.Specs = nil
+
+	var prevType ast.Expr
+	var gaps []bool
+	dropped := false
+	prevEnd := decl.Lparen
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+
+		specCopy := *valueSpec
+		if decl.Tok == token.CONST && specCopy.Type == nil && len(specCopy.Values) == 0 && prevType != nil {
+			specCopy.Type = copyConstType(prevType, valueSpec.Pos())
+		}
+
+		specCopy.Names = nil
+		exported := false
+		for _, name := range valueSpec.Names {
+			if name.IsExported() {
+				exported = true
+				specCopy.Names = append(specCopy.Names, name)
+				continue
+			}
+			specCopy.Names = append(specCopy.Names, &ast.Ident{NamePos: name.NamePos, Name: "_"})
+		}
+		if exported {
+			// the blank line printed in place of the specs left out is a gap,
+			// unless the spec was already preceded by a blank line
+			start := valueSpec.Pos()
+			if valueSpec.Doc != nil {
+				start = valueSpec.Doc.Pos()
+			}
+			blankBefore := fs.Position(start).Line-fs.Position(prevEnd).Line > 1
+
+			declCopy.Specs = append(declCopy.Specs, &specCopy)
+			gaps = append(gaps, dropped && !blankBefore)
+			dropped = false
+			prevType = nil
+		} else {
+			dropped = true
+			prevType = specCopy.Type
+		}
+		prevEnd = valueSpec.End()
+		if valueSpec.Comment != nil {
+			prevEnd = valueSpec.Comment.End()
+		}
+	}
+
+	// a single spec is printed without parentheses, unless it has a doc
+	// comment which would end up between the keyword and the name
+	if len(declCopy.Specs) == 1 && declCopy.Specs[0].(*ast.ValueSpec).Doc == nil {
+		declCopy.Lparen = token.NoPos
+		declCopy.Rparen = token.NoPos
+	}
+
+	return removeGaps(extractDecl(fs, &declCopy), gaps)
+}
+
+// removeGaps removes from a formatted declaration the blank lines printed
+// in place of the specs left out, before the specs flagged in gaps. The
+// declaration is parsed again to find the lines of its specs
+func removeGaps(source string, gaps []bool) string {
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, "", "package p\n"+source, parser.ParseComments)
+	if err != nil || len(file.Decls) != 1 {
+		return source
+	}
+	decl, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || len(decl.Specs) != len(gaps) {
+		return source
+	}
+
+	lines := strings.Split(source, "\n")
+	blank := make(map[int]bool)
+	for i, spec := range decl.Specs {
+		if !gaps[i] {
+			continue
+		}
+
+		pos := spec.Pos()
+		if doc := spec.(*ast.ValueSpec).Doc; doc != nil {
+			pos = doc.Pos()
+		}
+
+		// the first line is the package clause
+		line := fs.Position(pos).Line - 2
+		if line > 0 && strings.TrimSpace(lines[line-1]) == "" {
+			blank[line-1] = true
+		}
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !blank[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// copyConstType returns a copy of the type of a constant, positioned at pos
+// so that it is printed on the line of the spec it is copied to. Only named
+// types (T and pkg.T) are copied, which are the only ones constants have
+func copyConstType(typ ast.Expr, pos token.Pos) ast.Expr {
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		return &ast.Ident{Name: typ.Name, NamePos: pos}
+	case *ast.SelectorExpr:
+		if pkg, ok := typ.X.(*ast.Ident); ok {
+			return &ast.SelectorExpr{
+				X:   &ast.Ident{Name: pkg.Name, NamePos: pos},
+				Sel: &ast.Ident{Name: typ.Sel.Name, NamePos: pos},
+			}
+		}
+	}
+	return nil
+}
+
+// embeddedFieldName returns the name of an embedded field, which is the
+// name of its type without pointers, package qualifiers and type arguments
+func embeddedFieldName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldName(t.X)
+	case *ast.ParenExpr:
+		return embeddedFieldName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedFieldName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return formatExpr(expr)
+}
+
 // extractTag extracts struct tags
 func extractTag(field *ast.Field) string {
 	if field.Tag != nil {
@@ -63,8 +476,10 @@ type DescriptionData struct {
 }
 
 // extractDescriptionData extracts the description and example code from a
-// function's documentation comment
-func extractDescriptionData(doc string) DescriptionData {
+// function's documentation comment, the description, notes and deprecation
+// note are rendered to HTML following the Go doc comment syntax, with doc
+// links resolved by the given DocRenderer
+func extractDescriptionData(doc string, docs *DocRenderer) DescriptionData {
 	lines := strings.Split(doc, "\n")
 
 	var descLines []string
@@ -72,38 +487,43 @@ func extractDescriptionData(doc string) DescriptionData {
 	var notesLines []string
 	var deprecationNoteLines []string
 
-	var description string
 	var example string
-	var notes string
-	var deprecationNote string
 
 	isExample := false
 	isNotes := false
 	isDeprecationNote := false
 
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Example:") {
+		// lines are kept untrimmed since indentation is meaningful in doc
+		// comments (e.g. code blocks and lists)
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Example:") {
 			isExample = true
 			isNotes = false
 			isDeprecationNote = false
 			continue
 		}
-		if strings.HasPrefix(line, "Notes:") {
+		if strings.HasPrefix(trimmed, "Notes:") {
 			isNotes = true
 			isExample = false
 			isDeprecationNote = false
-			continue
+			line = strings.TrimSpace(strings.TrimPrefix(trimmed, "Notes:"))
+			if line == "" {
+				continue
+			}
 		}
-		if strings.HasPrefix(line, "Deprecated:") {
+		if strings.HasPrefix(trimmed, "Deprecated:") {
 			isDeprecationNote = true
 			isExample = false
 			isNotes = false
-			continue
+			line = strings.TrimSpace(strings.TrimPrefix(trimmed, "Deprecated:"))
+			if line == "" {
+				continue
+			}
 		}
 
 		if isExample {
-			exampleLines = append(exampleLines, line)
+			exampleLines = append(exampleLines, trimmed)
 		} else if isNotes {
 			notesLines = append(notesLines, line)
 		} else if isDeprecationNote {
@@ -114,13 +534,7 @@ func extractDescriptionData(doc string) DescriptionData {
 	}
 
 	// Description
-	descriptionRaw := strings.Join(descLines, "\n")
-	description = strings.Join(descLines, "</p>\n<p>")
-	description = "<p>" + description + "</p>"
-	description = strings.ReplaceAll(description, "\t", " ")
-	if description == "<p></p>" {
-		description = ""
-	}
+	descriptionRaw := strings.TrimSpace(strings.Join(descLines, "\n"))
 
 	// Example
 	example = strings.Join(exampleLines, "\n")
@@ -128,28 +542,14 @@ func extractDescriptionData(doc string) DescriptionData {
 	example = strings.TrimLeft(example, "\n")
 	example = formatExample(example)
 
-	// Notes
-	notes = strings.Join(notesLines, "</p>\n<p>")
-	notes = "<p>" + notes + "</p>"
-	notes = strings.ReplaceAll(notes, "\t", " ")
-	if notes == "<p></p>" {
-		notes = ""
-	}
-
 	// Deprecation Note
-	deprecationNoteRaw := strings.Join(deprecationNoteLines, "\n")
-	deprecationNote = strings.Join(deprecationNoteLines, "</p>\n<p>")
-	deprecationNote = "<p>" + deprecationNote + "</p>"
-	deprecationNote = strings.ReplaceAll(deprecationNote, "\t", " ")
-	if deprecationNote == "<p></p>" {
-		deprecationNote = ""
-	}
+	deprecationNoteRaw := strings.TrimSpace(strings.Join(deprecationNoteLines, "\n"))
 
 	return DescriptionData{
-		Description:     description,
+		Description:     docs.Render(strings.Join(descLines, "\n")),
 		Example:         example,
-		Notes:           notes,
-		DeprecationNote: deprecationNote,
+		Notes:           docs.Render(strings.Join(notesLines, "\n")),
+		DeprecationNote: docs.Render(strings.Join(deprecationNoteLines, "\n")),
 
 		// Raw fields
 		DescriptionRaw:     descriptionRaw,
@@ -194,12 +594,119 @@ func extractBody(fs *token.FileSet, fn *ast.FuncDecl) string {
 	start := fs.Position(fn.Body.Pos()).Offset
 	end := fs.Position(fn.Body.End()).Offset
 	fileContent, _ := os.ReadFile(fs.File(fn.Body.Pos()).Name())
-	body := string(fileContent[start:end])
+	// the body is plain source code, it is escaped by the templates
+	return string(fileContent[start:end])
+}
+
+// extractSignature extracts the signature of a function or method
+// declaration, including its receiver and type parameters
+func extractSignature(fs *token.FileSet, fn *ast.FuncDecl) string {
+	fnCopy := *fn
+	fnCopy.Doc = nil
+	fnCopy.Body = nil
+
+	var out strings.Builder
+	if err := format.Node(&out, fs, &fnCopy); err != nil {
+		return ""
+	}
+
+	return out.String()
+}
+
+// extractTypeSignature extracts the signature of a type declaration, the
+// underlying type is only included for non struct and interface types since
+// those are documented through their fields and methods
+func extractTypeSignature(spec *ast.TypeSpec) string {
+	signature := "type " + spec.Name.Name + formatTypeParams(spec.TypeParams)
+
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		signature += " struct"
+	case *ast.InterfaceType:
+		signature += " interface"
+	default:
+		if spec.Assign.IsValid() {
+			signature += " ="
+		}
+		signature += " " + formatExpr(spec.Type)
+	}
+
+	return signature
+}
+
+// formatTypeParams formats a type parameter list as it appears in the
+// source, e.g. [K comparable, V any]
+func formatTypeParams(fieldList *ast.FieldList) string {
+	if fieldList == nil || len(fieldList.List) == 0 {
+		return ""
+	}
+
+	var params []string
+	for _, param := range fieldList.List {
+		var names []string
+		for _, name := range param.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+formatExpr(param.Type))
+	}
+
+	return "[" + strings.Join(params, ", ") + "]"
+}
 
-	// before returning we have to escape possible html snippets in it since
-	// those snippets are rendered by highlighting.js which has an issue with
-	// unescaped html snippets (yeah even if inside a Go string, what a pleasure)
-	return html.EscapeString(body)
+// receiverTypeName returns the name of the type a method is declared on,
+// as resolved by the type checker, e.g. *Set[T] becomes Set
+func receiverTypeName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
+// extractLocation returns the file and the line range of a declaration,
+// the file is relative to the project root, or nil if it is unknown
+func extractLocation(fs *token.FileSet, node ast.Node, packagePath string) *SourceLocation {
+	start := fs.Position(node.Pos())
+	end := fs.Position(node.End())
+	if start.Filename == "" {
+		return nil
+	}
+
+	return &SourceLocation{
+		File:    filepath.ToSlash(filepath.Join(packagePath, filepath.Base(start.Filename))),
+		Line:    start.Line,
+		EndLine: end.Line,
+	}
+}
+
+// rootPackageURL is the name of the generated page for the package at the
+// root of the project
+const rootPackageURL = "_root"
+
+// packageURL returns the name of the generated page for a package, given
+// its path relative to the project
+func packageURL(relativePath string) string {
+	// The root package gets a name no other package can have, since the go
+	// tool ignores directories starting with an underscore
+	if relativePath == "." {
+		return rootPackageURL
+	}
+
+	// Replace slashes with hyphens to ensure unique filenames
+	return strings.ReplaceAll(relativePath, string(os.PathSeparator), "-")
 }
 
 // formatExpr formats an expression using the go/format package
@@ -211,46 +718,137 @@ func formatExpr(expr ast.Expr) string {
 	return out.String()
 }
 
-// findReferences finds references to other entities in an entity
-func findReferences(entity EntityInfo, entityIndex map[string]EntityInfo) []ReferenceInfo {
+// findReferences finds references to other entities of the project in an
+// entity, the referenced types are resolved by the type checker so entities
+// from other packages of the project are found too
+func findReferences(entity EntityInfo, fs *token.FileSet, projectPath string) []ReferenceInfo {
 	var references []ReferenceInfo
 
-	// Check for parameters
-	for _, param := range entity.Parameters {
-		paramType := strings.Split(param, " ")[1]
-		if refEntity, found := entityIndex[entity.Package+"."+paramType]; found {
-			references = append(references, ReferenceInfo{
-				Name:        paramType,
-				Package:     refEntity.Package,
-				PackageURL:  refEntity.PackageURL,
-				PackagePath: refEntity.PackagePath,
-			})
+	seen := make(map[types.Object]bool)
+	visited := make(map[types.Type]bool)
+	addReference := func(obj *types.TypeName) {
+		if seen[obj] || obj == entity.Object || obj.Pkg() == nil {
+			return
 		}
+		seen[obj] = true
+
+		// only entities declared in the project are documented
+		relativePath, ok := projectRelativePath(obj, fs, projectPath)
+		if !ok {
+			return
+		}
+
+		references = append(references, ReferenceInfo{
+			Name:        obj.Name(),
+			Package:     obj.Pkg().Name(),
+			PackageURL:  packageURL(relativePath),
+			PackagePath: relativePath,
+		})
 	}
 
-	// Check for returns
-	for _, ret := range entity.Returns {
-		if refEntity, found := entityIndex[entity.Package+"."+ret]; found {
-			references = append(references, ReferenceInfo{
-				Name:        ret,
-				Package:     refEntity.Package,
-				PackageURL:  refEntity.PackageURL,
-				PackagePath: refEntity.PackagePath,
-			})
+	for _, typ := range entityTypes(entity) {
+		collectNamedTypes(typ, visited, addReference)
+	}
+
+	return references
+}
+
+// projectRelativePath returns the path, relative to the project, of the
+// package declaring an object, reporting false if it is declared outside
+// of the project (e.g. standard library or third-party packages)
+func projectRelativePath(obj types.Object, fs *token.FileSet, projectPath string) (string, bool) {
+	file := fs.Position(obj.Pos()).Filename
+	if file == "" || !filepath.IsAbs(file) {
+		return "", false
+	}
+
+	relativePath, err := filepath.Rel(projectPath, filepath.Dir(file))
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return "", false
+	}
+
+	return relativePath, true
+}
+
+// entityTypes returns the types used by an entity: the signature of
+// functions and methods, the definition of types and the type of constants
+// and variables
+func entityTypes(entity EntityInfo) []types.Type {
+	var typs []types.Type
+
+	switch obj := entity.Object.(type) {
+	case *types.Func:
+		typs = append(typs, obj.Type())
+	case *types.TypeName:
+		typs = append(typs, obj.Type().Underlying())
+		if named, ok := obj.Type().(*types.Named); ok {
+			for i := 0; i < named.TypeParams().Len(); i++ {
+				typs = append(typs, named.TypeParams().At(i).Constraint())
+			}
 		}
 	}
 
-	// Check for fields
-	for _, field := range entity.Fields {
-		if refEntity, found := entityIndex[entity.Package+"."+field.Type]; found {
-			references = append(references, ReferenceInfo{
-				Name:        field.Type,
-				Package:     refEntity.Package,
-				PackageURL:  refEntity.PackageURL,
-				PackagePath: refEntity.PackagePath,
-			})
+	for _, value := range entity.Values {
+		if value.Object != nil {
+			typs = append(typs, value.Object.Type())
 		}
 	}
 
-	return references
+	return typs
+}
+
+// collectNamedTypes walks a type and calls fn for each named type it uses
+func collectNamedTypes(typ types.Type, visited map[types.Type]bool, fn func(*types.TypeName)) {
+	if typ == nil || visited[typ] {
+		return
+	}
+	visited[typ] = true
+
+	switch t := typ.(type) {
+	case *types.Named:
+		fn(t.Obj())
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			collectNamedTypes(t.TypeArgs().At(i), visited, fn)
+		}
+	case *types.Alias:
+		fn(t.Obj())
+	case *types.Pointer:
+		collectNamedTypes(t.Elem(), visited, fn)
+	case *types.Slice:
+		collectNamedTypes(t.Elem(), visited, fn)
+	case *types.Array:
+		collectNamedTypes(t.Elem(), visited, fn)
+	case *types.Chan:
+		collectNamedTypes(t.Elem(), visited, fn)
+	case *types.Map:
+		collectNamedTypes(t.Key(), visited, fn)
+		collectNamedTypes(t.Elem(), visited, fn)
+	case *types.Signature:
+		for i := 0; i < t.TypeParams().Len(); i++ {
+			collectNamedTypes(t.TypeParams().At(i).Constraint(), visited, fn)
+		}
+		collectNamedTypes(t.Params(), visited, fn)
+		collectNamedTypes(t.Results(), visited, fn)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			collectNamedTypes(t.At(i).Type(), visited, fn)
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			collectNamedTypes(t.Field(i).Type(), visited, fn)
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			collectNamedTypes(t.ExplicitMethod(i).Type(), visited, fn)
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			collectNamedTypes(t.EmbeddedType(i), visited, fn)
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			collectNamedTypes(t.Term(i).Type(), visited, fn)
+		}
+	case *types.TypeParam:
+		collectNamedTypes(t.Constraint(), visited, fn)
+	}
 }
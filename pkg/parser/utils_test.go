@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTypeSet(t *testing.T) {
+	parsed := parseFixture(t, map[string]string{
+		"fx.go": `package fx
+
+import "fmt"
+
+// Num is a number
+type Num interface{ ~int | ~float64 }
+
+// Small embeds a constraint
+type Small interface {
+	Num
+	~string | ~int
+}
+
+// Integer is an integer
+type Integer interface{ ~int | ~int64 }
+
+// Ordered unions constraints
+type Ordered interface{ Integer | ~float64 | ~string }
+
+// MyInt is an int
+type MyInt int
+
+// Mine narrows a term
+type Mine interface {
+	~int
+	MyInt
+}
+
+// Stringish has methods and terms
+type Stringish interface {
+	fmt.Stringer
+	~string
+}
+
+// Plain has methods only
+type Plain interface{ Read() }
+`,
+	}, VisibilityAll)
+
+	tests := []struct {
+		name    string
+		typeSet []string
+		embeds  []string
+	}{
+		{"Num", []string{"~int", "~float64"}, nil},
+		{"Small", []string{"~int"}, []string{"Num"}},
+		{"Ordered", []string{"~int", "~int64", "~float64", "~string"}, nil},
+		{"Mine", []string{"MyInt"}, nil},
+		{"Stringish", []string{"~string"}, []string{"fmt.Stringer"}},
+		{"Plain", nil, nil},
+	}
+
+	for _, test := range tests {
+		entity := findEntity(t, parsed["."], test.name)
+		if !reflect.DeepEqual(entity.TypeSet, test.typeSet) {
+			t.Errorf("%s type set = %q, want %q", test.name, entity.TypeSet, test.typeSet)
+		}
+		if !reflect.DeepEqual(entity.Embeds, test.embeds) {
+			t.Errorf("%s embeds = %q, want %q", test.name, entity.Embeds, test.embeds)
+		}
+	}
+}
+
+func TestExtractValues(t *testing.T) {
+	parsed := parseFixture(t, map[string]string{
+		"fx.go": `package fx
+
+// Mode is a mode
+type Mode int
+
+// Modes
+const (
+	// Read reads
+	Read Mode = 1 << iota // first
+	Write
+	Exec
+)
+
+// Sizes
+const (
+	KB = 1 << 10 // kilobyte
+	MB = KB << 10
+)
+`,
+	}, VisibilityAll)
+
+	tests := []struct {
+		block  string
+		values []ValueInfo
+	}{
+		{"Read", []ValueInfo{
+			{Name: "Read", Type: "Mode", Value: "1", Doc: "Read reads", Comment: "first"},
+			{Name: "Write", Type: "Mode", Value: "2", Implicit: true},
+			{Name: "Exec", Type: "Mode", Value: "4", Implicit: true},
+		}},
+		{"KB", []ValueInfo{
+			{Name: "KB", Value: "1 << 10", Comment: "kilobyte"},
+			{Name: "MB", Value: "KB << 10"},
+		}},
+	}
+
+	for _, test := range tests {
+		entity := findEntity(t, parsed["."], test.block)
+		for i := range entity.Values {
+			entity.Values[i].Object = nil
+		}
+		if !reflect.DeepEqual(entity.Values, test.values) {
+			t.Errorf("%s values = %+v, want %+v", test.block, entity.Values, test.values)
+		}
+	}
+}
+
+func TestExtractExportedDecl(t *testing.T) {
+	parsed := parseFixture(t, map[string]string{
+		"fx.go": `package fx
+
+import "time"
+
+// Level is a level
+type Level int
+
+var (
+	hidden = 1
+	Shown  = 2
+)
+
+var left, Right = 1, 2
+
+const (
+	first = iota
+	// Second is exported
+	Second
+	third
+)
+
+const (
+	low Level = iota
+	High
+	medium
+	Top
+)
+
+const (
+	A = 1
+	b = 2
+	C = 3 // C is three
+)
+
+const (
+	short = time.Second
+	Long
+)
+
+var (
+	// Template is a template
+	Template = ` + "`first\n\nlast`" + `
+
+	internal = 1
+
+	// Grouped is after a blank line
+	Grouped = 2
+)
+
+// Config has hidden fields
+type Config struct {
+	Name string
+	token string
+	Level
+	embedded
+}
+
+type embedded struct{}
+
+type closer interface{ Close() error }
+
+// Closer embeds
+type Closer interface {
+	closer
+	Flush()
+}
+`,
+	}, VisibilityExported)
+	pkgInfo := parsed["."]
+
+	tests := []struct {
+		block string
+		body  string
+	}{
+		{"Shown", "var Shown = 2"},
+		{"Right", "var _, Right = 1, 2"},
+		{"Second", "const (\n\t// Second is exported\n\tSecond\n)"},
+		// Top repeats the spec of High, as go doc shows it
+		{"High", "const (\n\tHigh Level\n\tTop\n)"},
+		{"A", "const (\n\tA = 1\n\tC = 3 // C is three\n)"},
+		// only explicit types are copied, as go doc does
+		{"Long", "const Long"},
+		// blank lines within values and between specs are kept
+		{"Template", "var (\n\t// Template is a template\n\tTemplate = `first\n\nlast`\n\n\t// Grouped is after a blank line\n\tGrouped = 2\n)"},
+	}
+
+	for _, test := range tests {
+		if body := findEntity(t, pkgInfo, test.block).Body; body != test.body {
+			t.Errorf("%s body = %q, want %q", test.block, body, test.body)
+		}
+	}
+
+	var fields []string
+	for _, field := range findEntity(t, pkgInfo, "Config").Fields {
+		fields = append(fields, field.Name)
+	}
+	if want := []string{"Name", "Level"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Config fields = %q, want %q", fields, want)
+	}
+	if embeds := findEntity(t, pkgInfo, "Closer").Embeds; len(embeds) != 0 {
+		t.Errorf("Closer embeds = %q, want none", embeds)
+	}
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// Visibility controls which identifiers are documented
+type Visibility string
+
+const (
+	// VisibilityExported documents the exported identifiers only, that is
+	// the public API of the packages
+	VisibilityExported Visibility = "exported"
+
+	// VisibilityAll documents every identifier, unexported ones included
+	VisibilityAll Visibility = "all"
+)
+
+// ParseVisibility parses a visibility mode
+//
+// Example:
+//
+//	visibility, err := parser.ParseVisibility("exported")
+//	if err != nil {
+//		log.Fatalf("Error parsing visibility: %v", err)
+//	}
+func ParseVisibility(mode string) (Visibility, error) {
+	switch Visibility(mode) {
+	case VisibilityExported, VisibilityAll:
+		return Visibility(mode), nil
+	}
+	return "", fmt.Errorf("invalid visibility %q, expected %q or %q", mode, VisibilityExported, VisibilityAll)
+}
+
+// FilterVisibility removes from a package the entities, fields, methods and
+// interface methods which are not documented with the given visibility
+//
+// Example:
+//
+//	pkgInfo = parser.FilterVisibility(pkgInfo, parser.VisibilityExported)
+//
+// Notes:
+// Const and var blocks are kept if at least one of their names is exported,
+// the block is then named after the first exported name
+func FilterVisibility(pkgInfo PackageInfo, visibility Visibility) PackageInfo {
+	if visibility == VisibilityAll {
+		return pkgInfo
+	}
+
+	var entities []EntityInfo
+	for _, entity := range pkgInfo.Entities {
+		if entity.Type == "const" || entity.Type == "var" {
+			var values []ValueInfo
+			for _, value := range entity.Values {
+				if token.IsExported(value.Name) {
+					values = append(values, value)
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+			entity.Values = values
+			entity.Name = values[0].Name
+			entity.Object = values[0].Object
+			entity.Body = entity.exportedBody
+		} else if !token.IsExported(entity.Name) {
+			continue
+		}
+
+		entity.Embeds = filterEmbeds(entity.Embeds)
+		entity.Fields = filterFields(entity.Fields)
+		entity.Methods = filterMethods(entity)
+		entity.Implements = filterImplementations(entity.Implements)
+		entity.References = filterReferences(entity.References)
+		entities = append(entities, entity)
+	}
+	pkgInfo.Entities = entities
+
+	return pkgInfo
+}
+
+// IsInternal reports whether the package is an internal package, which can
+// only be imported by the packages rooted at the parent of the internal
+// directory
+func (p PackageInfo) IsInternal() bool {
+	return isInternalPath(p.ImportPath)
+}
+
+// isInternalPath reports whether an import path is the one of an internal
+// package
+func isInternalPath(importPath string) bool {
+	for _, element := range strings.Split(importPath, "/") {
+		if element == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludeInternal removes the internal packages, and the links to them, so
+// that no link points to a page which is not generated: references to their
+// entities are dropped while the implemented interfaces and the promoted
+// methods they declare are kept as unlinked text
+//
+// Example:
+//
+//	packages = parser.ExcludeInternal(packages)
+//
+// Notes:
+// The doc comments and the source pages are rendered while parsing, their
+// links are left out by the LinkResolver (see LinkResolver.ExcludeInternal)
+func ExcludeInternal(packages []PackageInfo) []PackageInfo {
+	excluded := make(map[string]bool)
+	var public []PackageInfo
+	for _, pkgInfo := range packages {
+		if pkgInfo.IsInternal() {
+			excluded[pkgInfo.Path] = true
+			continue
+		}
+		public = append(public, pkgInfo)
+	}
+
+	for i := range public {
+		for j := range public[i].Entities {
+			unlinkEntity(&public[i].Entities[j], excluded)
+		}
+	}
+
+	return public
+}
+
+// unlinkEntity removes from an entity, and its methods, the links to the
+// excluded packages
+func unlinkEntity(entity *EntityInfo, excluded map[string]bool) {
+	var references []ReferenceInfo
+	for _, reference := range entity.References {
+		if !excluded[reference.PackagePath] {
+			references = append(references, reference)
+		}
+	}
+	entity.References = references
+
+	for i, implementation := range entity.Implements {
+		if excluded[implementation.PackagePath] {
+			entity.Implements[i].PackageURL = ""
+		}
+	}
+
+	for i := range entity.Methods {
+		if entity.Methods[i].PromotedFrom != "" && excluded[entity.Methods[i].PackagePath] {
+			entity.Methods[i].PackageURL = ""
+		}
+		unlinkEntity(&entity.Methods[i], excluded)
+	}
+}
+
+// filterFields removes the unexported fields of a struct, embedded fields
+// are named after their type so the same rule applies
+func filterFields(fields []FieldInfo) []FieldInfo {
+	var exported []FieldInfo
+	for _, field := range fields {
+		if token.IsExported(field.Name) {
+			exported = append(exported, field)
+		}
+	}
+	return exported
+}
+
+// filterEmbeds removes the unexported interfaces embedded in an interface,
+// e.g. hidden but not io.Reader
+func filterEmbeds(embeds []string) []string {
+	var exported []string
+	for _, embed := range embeds {
+		if token.IsExported(baseTypeName(embed)) {
+			exported = append(exported, embed)
+		}
+	}
+	return exported
+}
+
+// baseTypeName returns the name of a type without its package and type
+// arguments, e.g. Reader for io.Reader
+func baseTypeName(typeString string) string {
+	name, _, _ := strings.Cut(typeString, "[")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// promotedThroughUnexported reports whether a method is promoted through an
+// unexported embedded field or interface, which is not documented
+func promotedThroughUnexported(entity EntityInfo, method EntityInfo) bool {
+	if method.PromotedFrom == "" {
+		return false
+	}
+	if entity.Type == "interface" {
+		return !token.IsExported(baseTypeName(method.PromotedFrom))
+	}
+	for _, field := range strings.Split(method.PromotedFrom, ".") {
+		if !token.IsExported(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMethods removes the unexported methods of a type or interface, the
+// methods promoted through unexported embedded types are documented as its
+// own (see ownMethod)
+func filterMethods(entity EntityInfo) []EntityInfo {
+	var exported []EntityInfo
+	for _, method := range entity.Methods {
+		if token.IsExported(method.Name) {
+			if promotedThroughUnexported(entity, method) {
+				method = ownMethod(entity, method)
+			}
+			method.References = filterReferences(method.References)
+			exported = append(exported, method)
+		}
+	}
+	return exported
+}
+
+// filterImplementations removes the unexported project interfaces, which
+// are not documented
+func filterImplementations(implementations []ImplementationInfo) []ImplementationInfo {
+	var exported []ImplementationInfo
+	for _, implementation := range implementations {
+		if implementation.PackageURL == "" || token.IsExported(implementation.InterfaceName) {
+			exported = append(exported, implementation)
+		}
+	}
+	return exported
+}
+
+// filterReferences removes the references to unexported project entities,
+// which are not documented
+func filterReferences(references []ReferenceInfo) []ReferenceInfo {
+	var exported []ReferenceInfo
+	for _, reference := range references {
+		if reference.PackageURL == "" || token.IsExported(reference.Name) {
+			exported = append(exported, reference)
+		}
+	}
+	return exported
+}
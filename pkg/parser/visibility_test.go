@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterVisibility(t *testing.T) {
+	parsed := parseFixture(t, map[string]string{
+		"fx.go": `package fx
+
+const (
+	first = iota
+	// Second is exported
+	Second
+	third
+)
+
+const hiddenConst = 1
+
+// Config is a config
+type Config struct {
+	Name  string
+	token string
+	Embedded
+	embedded
+}
+
+// Embedded is embedded
+type Embedded struct{}
+
+type embedded struct{}
+
+// Load loads
+func (Config) Load() {}
+
+func (Config) reload() {}
+
+// Closer embeds
+type Closer interface {
+	closer
+	Flush()
+	flush()
+}
+
+type closer interface{ Close() error }
+
+func helper() {}
+`,
+	}, VisibilityExported)
+	pkgInfo := parsed["."]
+
+	var names []string
+	for _, entity := range pkgInfo.Entities {
+		names = append(names, entity.Name)
+	}
+	if want := []string{"Second", "Config", "Embedded", "Closer"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entities = %q, want %q", names, want)
+	}
+
+	block := findEntity(t, pkgInfo, "Second")
+	if len(block.Values) != 1 || block.Values[0].Name != "Second" {
+		t.Errorf("block values = %+v, want Second only", block.Values)
+	}
+
+	config := findEntity(t, pkgInfo, "Config")
+	var fields []string
+	for _, field := range config.Fields {
+		fields = append(fields, field.Name)
+	}
+	if want := []string{"Name", "Embedded"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Config fields = %q, want %q", fields, want)
+	}
+	if len(config.Methods) != 1 || config.Methods[0].Name != "Load" {
+		t.Errorf("Config methods = %+v, want Load only", config.Methods)
+	}
+
+	closer := findEntity(t, pkgInfo, "Closer")
+	var methods []string
+	for _, method := range closer.Methods {
+		methods = append(methods, method.Name)
+	}
+	if want := []string{"Flush", "Close"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("Closer methods = %q, want %q", methods, want)
+	}
+	if len(closer.Embeds) != 0 {
+		t.Errorf("Closer embeds = %q, want none", closer.Embeds)
+	}
+}
+
+func TestFilterEmbeds(t *testing.T) {
+	embeds := []string{"io.Reader", "hidden", "lib.hidden", "List[T]", "list[T]", "lib.List[lib.item]", "lib.list[Item]"}
+	want := []string{"io.Reader", "List[T]", "lib.List[lib.item]"}
+	if got := filterEmbeds(embeds); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterEmbeds(%q) = %q, want %q", embeds, got, want)
+	}
+}
+
+func TestExcludeInternal(t *testing.T) {
+	packages := ExcludeInternal([]PackageInfo{
+		{ImportPath: "example.com/fx/internal/secret", Path: "internal/secret"},
+		{ImportPath: "example.com/fx/api", Path: "api", Entities: []EntityInfo{{
+			Name: "File",
+			Implements: []ImplementationInfo{
+				{InterfaceName: "Opener", PackageURL: "internal-secret", PackagePath: "internal/secret"},
+				{InterfaceName: "Reader", PackageURL: "lib", PackagePath: "lib"},
+			},
+			References: []ReferenceInfo{
+				{Name: "Key", PackageURL: "internal-secret", PackagePath: "internal/secret"},
+				{Name: "Base", PackageURL: "lib", PackagePath: "lib"},
+			},
+			Methods: []EntityInfo{
+				{Name: "Open", PromotedFrom: "Key", PackageURL: "internal-secret", PackagePath: "internal/secret"},
+				{Name: "Run", PromotedFrom: "Base", PackageURL: "lib", PackagePath: "lib"},
+				{Name: "Use", References: []ReferenceInfo{{Name: "Key", PackageURL: "internal-secret", PackagePath: "internal/secret"}}},
+			},
+		}}},
+	})
+
+	if len(packages) != 1 || packages[0].Path != "api" {
+		t.Fatalf("ExcludeInternal() = %+v, want api only", packages)
+	}
+
+	file := packages[0].Entities[0]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"internal interface", file.Implements[0].PackageURL, ""},
+		{"interface", file.Implements[1].PackageURL, "lib"},
+		{"method promoted from an internal package", file.Methods[0].PackageURL, ""},
+		{"promoted method", file.Methods[1].PackageURL, "lib"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s link = %q, want %q", test.name, test.got, test.want)
+		}
+	}
+
+	if len(file.References) != 1 || file.References[0].Name != "Base" {
+		t.Errorf("references = %+v, want Base only", file.References)
+	}
+	if len(file.Methods[2].References) != 0 {
+		t.Errorf("method references = %+v, want none", file.Methods[2].References)
+	}
+}
+
+func TestFilterVisibilityUnexportedEmbedding(t *testing.T) {
+	parsed := parseFixture(t, map[string]string{
+		"lib/lib.go": "package lib\n\ntype inner struct{}\n\n// Run runs\nfunc (inner) Run(n int) error { return nil }\n\n// Base is embedded\ntype Base struct{ inner }\n",
+		"api/api.go": "package api\n\nimport \"example.com/fx/lib\"\n\ntype base struct{}\n\n// Do does\nfunc (b *base) Do() {}\n\ntype hidden interface{ Close() error }\n\n// Outer embeds\ntype Outer struct {\n\tbase\n\tlib.Base\n}\n\n// Closer embeds\ntype Closer interface{ hidden }\n",
+	}, VisibilityExported)
+
+	tests := []struct {
+		entity    string
+		method    string
+		signature string
+	}{
+		{"Outer", "Do", "func (b *Outer) Do()"},
+		{"Outer", "Run", "func (Outer) Run(n int) error"},
+		{"Closer", "Close", ""},
+	}
+
+	for _, test := range tests {
+		entity := findEntity(t, parsed["api"], test.entity)
+		var found bool
+		for _, method := range entity.Methods {
+			if method.Name != test.method {
+				continue
+			}
+			found = true
+			if method.PromotedFrom != "" {
+				t.Errorf("%s.%s is promoted from %q, which is not documented", test.entity, test.method, method.PromotedFrom)
+			}
+			if method.Receiver != test.entity {
+				t.Errorf("%s.%s receiver = %q, want %q", test.entity, test.method, method.Receiver, test.entity)
+			}
+			if method.Signature != test.signature {
+				t.Errorf("%s.%s signature = %q, want %q", test.entity, test.method, method.Signature, test.signature)
+			}
+		}
+		if !found {
+			t.Errorf("method %s.%s not found", test.entity, test.method)
+		}
+	}
+}